@@ -5,18 +5,30 @@ package cmd
 
 import (
 	"aliax/internal/cfg"
+	"io"
 	"os"
 
+	"github.com/caarlos0/log"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // Version will be set by the build process using -ldflags.
 // Do not modify this variable directly. It is populated at build time with the desired version string.
 var Version = ""
 
+// aliaxCmdParameter stores the global parameters shared by every command.
+type aliaxCmdParameter struct {
+	// dir is the directory to run as if aliax was started in, like git -C.
+	dir string
+	// config overrides the discovered configuration file.
+	config string
+}
+
 var (
-	config   = cfg.Name()
-	aliaxCmd = &cobra.Command{
+	config         string
+	aliaxParameter aliaxCmdParameter
+	aliaxCmd       = &cobra.Command{
 		Use:   "aliax",
 		Short: "A CLI tool for managing and extending commands",
 		Long: `Aliax is a command-line tool designed to enhance workflow efficiency by:
@@ -24,9 +36,68 @@ var (
 - Managing command aliases within a workspace.
 - Creating new custom commands to streamline repetitive tasks.
 `,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if err := applyWorkspace(); err != nil {
+				log.WithError(err).Fatal("changing directory")
+			}
+			config = cfg.Name()
+		},
 	}
 )
 
+// scriptFlags holds the flags configuring how scripts are run, they're local
+// to the root command and the commands running scripts.
+var scriptFlags = pflag.NewFlagSet("scripts", pflag.ContinueOnError)
+
+func init() {
+	flags := aliaxCmd.PersistentFlags()
+	flags.StringVarP(&aliaxParameter.dir, "directory", "C", "", "Run as if aliax was started in the given directory")
+	flags.StringVar(&aliaxParameter.config, "config", "", "Use the given configuration file instead of discovering it")
+	scriptFlags.BoolVarP(&ScriptOptions.Verbose, "verbose", "v", false, "Enable debug output when running scripts")
+	scriptFlags.BoolVarP(&ScriptOptions.Dry, "dry", "d", false, "Print the commands of the scripts instead of running them")
+	scriptFlags.BoolVar(&ScriptOptions.Parallel, "parallel", false, "Run every named script concurrently")
+	scriptFlags.IntVarP(&ScriptOptions.Jobs, "jobs", "j", 0, "Maximum number of scripts running at once, the number of CPUs by default")
+	scriptFlags.BoolVar(&ScriptOptions.Force, "force", false, "Run scripts even when their sources and generated files are unchanged")
+	scriptFlags.BoolVar(&ScriptOptions.Why, "why", false, "Explain why scripts run or are skipped")
+	aliaxCmd.Flags().AddFlagSet(scriptFlags)
+}
+
+// ParseGlobalFlags parses the global flags placed before the command or
+// script in args and applies -C and --config, it returns the remaining
+// arguments. The flags are the ones of the root command, so that cobra
+// parses the same ones when they follow a built-in command.
+func ParseGlobalFlags(args []string) ([]string, error) {
+	flags := pflag.NewFlagSet("aliax", pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.SetInterspersed(false)
+	flags.AddFlagSet(aliaxCmd.PersistentFlags())
+	flags.AddFlagSet(scriptFlags)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := applyWorkspace(); err != nil {
+		log.WithError(err).Fatal("changing directory")
+	}
+	return flags.Args(), nil
+}
+
+// applyWorkspace applies -C and --config and clears them,
+// so that the ones parsed by ParseGlobalFlags aren't applied twice.
+func applyWorkspace() error {
+	dir, file := aliaxParameter.dir, aliaxParameter.config
+	aliaxParameter.dir, aliaxParameter.config = "", ""
+	if len(dir) > 0 {
+		if err := os.Chdir(dir); err != nil {
+			return err
+		}
+	}
+	// relative files are resolved after changing the directory
+	if len(file) > 0 {
+		cfg.SetConfig(file)
+	}
+	return nil
+}
+
 func Execute() {
 	err := aliaxCmd.Execute()
 	if err != nil {
@@ -6,6 +6,7 @@ package cmd
 import (
 	"aliax/internal/aos"
 	"aliax/internal/cfg"
+	"aliax/internal/runner"
 	"io/fs"
 	"path/filepath"
 	"strings"
@@ -19,14 +20,14 @@ var (
 		Use:   "clean",
 		Short: "Remove generated scripts and clean up workspace",
 		Long: `The "clean" command removes all auto-generated scripts from the workspace.
-It scans the "run-scripts" directory and deletes scripts (e.g. .ps1, .sh).
-Additionally, it ensures that outdated extended commands are cleared.`,
+It scans the "run-scripts" directory and deletes scripts (e.g. .ps1, .sh, .fish).
+Additionally, it ensures that outdated extended commands are cleared
+and forgets the fingerprints of incremental scripts.`,
 		Example: "  aliax clean",
 		Run: func(cmd *cobra.Command, args []string) {
-			var file cfg.Aliax
-			err := aos.ReadYAML(config, &file)
+			file, err := cfg.Load(config)
 			if err != nil {
-				log.WithError(err).Fatalf("fail to parse file")
+				FatalConfig(err, "fail to parse file")
 			}
 
 			bins := map[string]struct{}{}
@@ -35,13 +36,15 @@ Additionally, it ensures that outdated extended commands are cleared.`,
 			}
 			log.Info("cleaning")
 			log.IncreasePadding()
-			err = filepath.Walk("run-scripts", func(path string, info fs.FileInfo, err error) error {
+			err = filepath.Walk(runPath(file), func(path string, info fs.FileInfo, err error) error {
 				if err != nil {
 					return err
 				}
 				if strings.HasSuffix(path, ".ps1") ||
 					strings.HasSuffix(path, ".sh") ||
-					filepath.Base(filepath.Dir(path)) == "bash" {
+					strings.HasSuffix(path, ".fish") ||
+					filepath.Base(filepath.Dir(path)) == "bash" ||
+					filepath.Base(filepath.Dir(path)) == "zsh" {
 					err = aos.Remove(path)
 					if err != nil {
 						log.WithError(err).Errorf("fail to remove file")
@@ -54,6 +57,12 @@ Additionally, it ensures that outdated extended commands are cleared.`,
 			if err != nil {
 				log.WithError(err).Fatal("fail to walk run-scripts")
 			}
+			cache := filepath.Join(cfg.Root(), runner.CacheFile)
+			if ok, _ := aos.Exist(cache); ok {
+				if err = aos.Remove(cache); err != nil {
+					log.WithError(err).Fatal("fail to remove script cache")
+				}
+			}
 		},
 	}
 )
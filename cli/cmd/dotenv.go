@@ -0,0 +1,106 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	fishast "aliax/internal/ast/fish"
+	"aliax/internal/dotenv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/caarlos0/log"
+	"github.com/spf13/cobra"
+)
+
+// dotenvCmdParameter stores parameters for the "dotenv" command.
+type dotenvCmdParameter struct {
+	shell string
+}
+
+var (
+	dotenvParameter dotenvCmdParameter
+	dotenvCmd       = &cobra.Command{
+		Use:   "dotenv <file>...",
+		Short: "Print the variables of dotenv files as statements of a shell",
+		Long: `The "dotenv" command parses the dotenv files the way "aliax run" does and prints the statements
+exporting their variables in the given shell. The scripts generated by "aliax init" evaluate them,
+so that every shell supports the same dotenv syntax. The files see the variables of the previous ones.`,
+		Example: "  eval \"$(aliax dotenv .env)\"\n  aliax dotenv --shell powershell .env | Out-String | Invoke-Expression",
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := printDotenv(os.Stdout, dotenvParameter.shell, args); err != nil {
+				log.WithError(err).Fatal("reading dotenv files")
+			}
+		},
+	}
+)
+
+// printDotenv writes the statements exporting the variables of the dotenv
+// files in shell to w, the variables the shell can't set are skipped.
+func printDotenv(w io.Writer, shell string, files []string) error {
+	format, ok := dotenvFormats[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell: %s", shell)
+	}
+	loaded := map[string]string{}
+	lookup := func(key string) (string, bool) {
+		if v, ok := loaded[key]; ok {
+			return v, true
+		}
+		return os.LookupEnv(key)
+	}
+	for _, name := range files {
+		vars, err := dotenv.ReadFile(name, lookup)
+		if err != nil {
+			return err
+		}
+		for _, v := range vars {
+			loaded[v.Key] = v.Value
+			if !format.name.MatchString(v.Key) {
+				log.WithField("file", name).Warnf("%s can't be set in %s, it's ignored", v.Key, shell)
+				continue
+			}
+			fmt.Fprintln(w, format.export(v.Key, v.Value))
+		}
+	}
+	return nil
+}
+
+// dotenvFormat is how a shell exports a variable,
+// name matches the names of the variables it can set.
+type dotenvFormat struct {
+	name   *regexp.Regexp
+	export func(k, v string) string
+}
+
+var (
+	shellName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	dotenvFormats = map[string]dotenvFormat{
+		"bash": {shellName, func(k, v string) string { return fmt.Sprintf("export %s=%s", k, bashQuote(v)) }},
+		"zsh":  {shellName, func(k, v string) string { return fmt.Sprintf("export %s=%s", k, bashQuote(v)) }},
+		// the variables are scoped to the function sourcing them, see fishScriptBuilder.buildEnvStmt
+		"fish": {shellName, func(k, v string) string { return fmt.Sprintf("set -fx %s %s", k, fishast.Quote(v)) }},
+		"powershell": {regexp.MustCompile(`^[\w.-]+$`), func(k, v string) string {
+			return fmt.Sprintf("${env:%s} = %s", k, psQuote(v))
+		}},
+	}
+)
+
+func init() {
+	aliaxCmd.AddCommand(dotenvCmd)
+	dotenvCmd.Flags().StringVarP(&dotenvParameter.shell, "shell", "s", "bash", "Shell to print the statements for: bash, zsh, fish or powershell")
+}
+
+// dotenvCommand returns the command printing the variables of the dotenv
+// files in shell, the files are quoted with quote.
+func dotenvCommand(shell string, files []string, quote func(string) string) string {
+	cmd := "aliax dotenv --shell " + shell
+	for _, name := range files {
+		cmd += " " + quote(name)
+	}
+	return cmd
+}
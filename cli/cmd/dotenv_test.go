@@ -0,0 +1,31 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintDotenv(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.env"), filepath.Join(dir, "second.env")
+	assert.NoError(t, os.WriteFile(first, []byte("export A=it's # comment\nB=\"${A}\\tb\"\na.b=1\n"), 0644))
+	assert.NoError(t, os.WriteFile(second, []byte("C='${A}'\nD=$B\n"), 0644))
+	for shell, want := range map[string]string{
+		"bash":       "export A='it'\\''s'\nexport B='it'\\''s\tb'\nexport C='${A}'\nexport D='it'\\''s\tb'\n",
+		"fish":       "set -fx A 'it\\'s'\nset -fx B 'it\\'s\tb'\nset -fx C '${A}'\nset -fx D 'it\\'s\tb'\n",
+		"powershell": "${env:A} = 'it''s'\n${env:B} = 'it''s\tb'\n${env:a.b} = '1'\n${env:C} = '${A}'\n${env:D} = 'it''s\tb'\n",
+	} {
+		buf := &strings.Builder{}
+		assert.NoError(t, printDotenv(buf, shell, []string{first, second}))
+		assert.Equal(t, want, buf.String(), shell)
+	}
+	assert.Error(t, printDotenv(&strings.Builder{}, "cmd", []string{first}))
+	assert.Error(t, printDotenv(&strings.Builder{}, "bash", []string{filepath.Join(dir, "missing.env")}))
+}
@@ -9,8 +9,10 @@ import (
 	bashast "aliax/internal/ast/bash"
 	psast "aliax/internal/ast/powershell"
 	"aliax/internal/cfg"
+	"aliax/internal/cond"
 	"aliax/internal/shell"
 	"aliax/internal/style"
+	"aliax/internal/template"
 	bashtoken "aliax/internal/token/bash"
 	token "aliax/internal/token/powershell"
 	"errors"
@@ -21,14 +23,12 @@ import (
 	"fmt"
 	"os"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 
 	"aliax/internal/log"
 	"github.com/ansurfen/globalenv"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 // initCmdParameter stores parameters for the "init" command.
@@ -38,6 +38,7 @@ type initCmdParameter struct {
 	verbose  bool
 	template string
 	save     bool
+	all      bool
 }
 
 var (
@@ -47,8 +48,13 @@ var (
 		Short: "Initialize the aliax workspace and generate execution scripts",
 		Long: `The "init" command scans the aliax configuration file and generates necessary execution scripts.
 It creates platform-specific scripts in the "run-scripts" directory for alias commands and extensions.
-If the --global (-g) flag is set, it applies configurations globally.`,
-		Example: "  aliax init\n  aliax init --global",
+The fish functions are written to "run-scripts/fish", add it to $fish_function_path to autoload them.
+The zsh functions and their completions are written to "run-scripts/zsh", add it to $fpath to autoload them.
+The scripts load their dotenv files with "aliax dotenv", so aliax must be on the PATH when they run,
+and the variables they set don't outlive them, PowerShell scripts restore the environment of the session.
+If the --global (-g) flag is set, it applies configurations globally.
+If the --all (-a) flag is set, it generates scripts for every configuration used by aliax.work.`,
+		Example: "  aliax init\n  aliax init --global\n  aliax init --all",
 		Run: func(cmd *cobra.Command, args []string) {
 			var start time.Time
 			if initParameter.verbose {
@@ -56,30 +62,67 @@ If the --global (-g) flag is set, it applies configurations globally.`,
 				log.SetLevel(log.DebugLevel)
 			}
 			if len(initParameter.template) > 0 {
-				config = filepath.Join(aos.TemplatePath, initParameter.template+".yaml")
+				config = cfg.Lookup(aos.TemplatePath, initParameter.template)
+				if len(config) == 0 {
+					log.WithField("template", initParameter.template).Fatal("template not found")
+				}
 			}
-			var file cfg.Aliax
-			err := aos.ReadYAML(config, &file)
-			if err != nil {
-				log.WithError(err).Fatalf("fail to parse file")
+			var (
+				file *cfg.Aliax
+				err  error
+			)
+			if initParameter.all {
+				name := cfg.WorkFile()
+				if len(name) == 0 {
+					log.WithField("suggestion", fmt.Sprintf("run %s to create one", style.Keyword("aliax work init"))).Fatal("aliax.work not found")
+				}
+				w, err := cfg.ReadWork(name)
+				if err != nil {
+					log.WithError(err).Fatal("fail to parse aliax.work")
+				}
+				file, err = cfg.LoadWork(name, w)
+				if err != nil {
+					FatalConfig(err, "fail to load workspace")
+				}
+			} else {
+				file, err = cfg.Load(config)
+				if err != nil {
+					FatalConfig(err, "fail to parse file")
+				}
+			}
+			for _, name := range file.Outdated() {
+				log.WithField("file", name).
+					WithField("suggestion", fmt.Sprintf("run %s to upgrade it", style.Keyword("aliax migrate"))).
+					Warn("configuration written for an older schema version")
+			}
+			for _, e := range file.Lint() {
+				log.WithField("suggestion", "set the priority of the cases, or order: declared").Warn(e.Error())
 			}
 			if initParameter.save {
 				path := filepath.Join(aos.TemplatePath, filepath.Base(config))
+				// the template keeps the format of the configuration
+				data, err := cfg.Marshal(path, file)
+				if err != nil {
+					log.WithError(err).Fatal("backuping template")
+				}
 				output, err := aos.Create(path)
 				if err != nil {
 					log.WithError(err).Fatal("fail to create file")
 				}
-				err = yaml.NewEncoder(output).Encode(file)
-				if err != nil {
+				defer output.Close()
+				if _, err = output.Write(data); err != nil {
 					log.WithError(err).Fatal("backuping template")
 				}
 			}
-			err = aos.MkdirAll("run-scripts/bash", 0755)
-			if err != nil {
-				if errors.Is(err, os.ErrExist) {
-					log.WithError(err).Warn("making run-scripts directory")
-				} else {
-					log.WithError(err).Fatal("making run-scripts directory")
+			file.RunPath = runPath(file)
+			for _, dir := range []string{"bash", "fish", "zsh"} {
+				err = aos.MkdirAll(filepath.Join(file.RunPath, dir), 0755)
+				if err != nil {
+					if errors.Is(err, os.ErrExist) {
+						log.WithError(err).Warn("making run-scripts directory")
+					} else {
+						log.WithError(err).Fatal("making run-scripts directory")
+					}
 				}
 			}
 
@@ -106,10 +149,12 @@ If the --global (-g) flag is set, it applies configurations globally.`,
 				file.Extend[name].Bin = ext.Bin
 			}
 
-			builder := &runScriptsBuilder{}
-
-			if len(file.RunPath) == 0 {
-				file.RunPath = "run-scripts"
+			if err = renderEnv(file); err != nil {
+				log.WithError(err).Fatal("rendering env")
+			}
+			builder := &runScriptsBuilder{dotenv: dotenvFiles(file.Dotenv), env: file.Env}
+			if file.RunFromRoot {
+				builder.root = cfg.Root()
 			}
 
 			if len(file.Executable) != 0 {
@@ -118,16 +163,16 @@ If the --global (-g) flag is set, it applies configurations globally.`,
 
 			err = builder.generateScriptExtension(file.RunPath, file.Extend)
 			if err != nil {
-				log.WithError(err).Fatal("generating extension script")
+				FatalConfig(err, "generating extension script")
 			}
 
 			err = builder.generateCommand(file.RunPath, file.Command)
 			if err != nil {
-				log.WithError(err).Fatal("generating command script")
+				FatalConfig(err, "generating command script")
 			}
 
 			if initParameter.global {
-				if err = setGlobal(); err != nil {
+				if err = setGlobal(file.RunPath); err != nil {
 					log.WithError(err).Fatal("setting for the global")
 				} else {
 					data, err := getAliaxPath()
@@ -152,7 +197,13 @@ If the --global (-g) flag is set, it applies configurations globally.`,
 	}
 )
 
-type runScriptsBuilder struct{}
+type runScriptsBuilder struct {
+	// dotenv and env are the top-level environment, set by every generated script.
+	dotenv []string
+	env    map[string]string
+	// root is the directory the generated scripts change into, if any.
+	root string
+}
 
 func (s *runScriptsBuilder) generateScriptExtension(dir string, cmds map[string]*cfg.Command) error {
 	for name, cmd := range cmds {
@@ -166,6 +217,16 @@ func (s *runScriptsBuilder) generateScriptExtension(dir string, cmds map[string]
 			log.WithError(err).Fatal("generating bash script")
 		}
 
+		_, err = s.generateFishExtension(dir, name, cmd)
+		if err != nil {
+			log.WithError(err).Fatal("generating fish script")
+		}
+
+		_, err = s.generateZshExtension(dir, name, cmd)
+		if err != nil {
+			log.WithError(err).Fatal("generating zsh script")
+		}
+
 		target, err := filepath.Abs(sh.filename())
 		if err != nil {
 			log.WithError(err).Fatal("invalid path")
@@ -186,6 +247,7 @@ func (s *runScriptsBuilder) generatePowershellExtension(dir, name string, cmd *c
 		return nil, err
 	}
 	defer psBuilder.close()
+	psBuilder.node.Append(psBuilder.buildEnvStmt(s.dotenv, s.env)...)
 	psBuilder.node.Append(psast.AssignStatement(
 		psast.RefRaw(executable),
 		psast.String(psBuilder.cmd.Bin),
@@ -198,6 +260,7 @@ func (s *runScriptsBuilder) generatePowershellExtension(dir, name string, cmd *c
 		Func: psast.RefRaw(executable),
 		Recv: []psast.Expr{psast.RefRaw("args")},
 	})
+	psBuilder.isolate(s.root)
 	psast.Print(psBuilder.node, psBuilder.file)
 	return psBuilder, nil
 }
@@ -209,6 +272,8 @@ func (s *runScriptsBuilder) generateBashExtension(dir, name string, cmd *cfg.Com
 		return nil, err
 	}
 	defer bashBuiler.close()
+	bashBuiler.node.Append(bashBuiler.buildDirStmt(s.root)...)
+	bashBuiler.node.Append(bashBuiler.buildEnvStmt(s.dotenv, s.env)...)
 	bashBuiler.node.Append(bashast.AssignStatement(
 		bashast.Identifier(executable),
 		bashast.String(cmd.Bin),
@@ -265,6 +330,16 @@ func (s *runScriptsBuilder) generateCommand(dir string, cmds map[string]*cfg.Com
 			log.WithError(err).Fatal("generating bash script")
 		}
 
+		_, err = s.generateFishCommand(dir, name, cmd)
+		if err != nil {
+			log.WithError(err).Fatal("generating fish script")
+		}
+
+		_, err = s.generateZshCommand(dir, name, cmd)
+		if err != nil {
+			log.WithError(err).Fatal("generating zsh script")
+		}
+
 		target, err := filepath.Abs(sh.filename())
 		if err != nil {
 			log.WithError(err).Fatal("invalid path")
@@ -286,8 +361,10 @@ func (s *runScriptsBuilder) generatePowershellCommand(dir, name string, cmd *cfg
 		return nil, err
 	}
 	defer psBuilder.close()
+	psBuilder.node.Append(psBuilder.buildEnvStmt(s.dotenv, s.env)...)
 
 	psBuilder.node.Append(psBuilder.generateCommand(name, name, 0, cmd)...)
+	psBuilder.isolate(s.root)
 
 	psast.Print(psBuilder.node, psBuilder.file)
 	return psBuilder, nil
@@ -300,6 +377,8 @@ func (s *runScriptsBuilder) generateBashCommand(dir, name string, cmd *cfg.Comma
 		return nil, err
 	}
 	defer bashBuiler.close()
+	bashBuiler.node.Append(bashBuiler.buildDirStmt(s.root)...)
+	bashBuiler.node.Append(bashBuiler.buildEnvStmt(s.dotenv, s.env)...)
 	bashBuiler.node.Append(bashast.RawStmt(`args=("$@")`))
 
 	bashBuiler.node.Append(bashBuiler.generateCommand(name, name, 0, cmd)...)
@@ -310,18 +389,26 @@ func (s *runScriptsBuilder) generateBashCommand(dir, name string, cmd *cfg.Comma
 	return bashBuiler, nil
 }
 
-type flagType uint8
+// bashQuote returns s as a single-quoted bash string.
+func bashQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
 
-const (
-	flagTypeString flagType = iota
-	flagTypeBool
-)
+// bashRegex escapes the characters the shell would interpret on the
+// right-hand side of =~, the regular expression must stay unquoted.
+func bashRegex(re string) string {
+	return regexp.MustCompile("([ ;&<>\"'`#])").ReplaceAllString(re, `\$1`)
+}
 
 type bashScriptBuilder struct {
 	file  *os.File
 	cmd   *cfg.Command
 	node  *bashast.File
 	ident string
+	// shell is the shell running the script, bash or zsh which runs the same
+	// statements, the match cases of its platform are selected. zsh falls
+	// back to the bash cases when none are written for it.
+	shell string
 }
 
 func newBashScriptBuilder(dir, name string, cmd *cfg.Command) (*bashScriptBuilder, error) {
@@ -344,6 +431,7 @@ func newBashScriptBuilder(dir, name string, cmd *cfg.Command) (*bashScriptBuilde
 		node:  &bashast.File{},
 		cmd:   cmd,
 		ident: name,
+		shell: "bash",
 	}
 
 	builder.node.Append(
@@ -407,7 +495,8 @@ func (b *bashScriptBuilder) buildBlockStmt4Command(subCommand []bashast.Stmt, id
 
 func (b *bashScriptBuilder) collectSubCommand4Extend(ident string, level int, cmd *cfg.Command) []bashast.Stmt {
 	subCommand := []bashast.Stmt{}
-	for name, subcmd := range cmd.Command {
+	for _, name := range cfg.SortedKeys(cmd.Command) {
+		subcmd := cmd.Command[name]
 		subcmd.SetName(name)
 		subCommand = append(subCommand, b.generateExtension(fmt.Sprintf("%s_%s", ident, name), name, level+1, subcmd)...)
 	}
@@ -416,7 +505,8 @@ func (b *bashScriptBuilder) collectSubCommand4Extend(ident string, level int, cm
 
 func (b *bashScriptBuilder) collectSubCommand4Command(ident string, level int, cmd *cfg.Command) []bashast.Stmt {
 	subCommand := []bashast.Stmt{}
-	for name, subcmd := range cmd.Command {
+	for _, name := range cfg.SortedKeys(cmd.Command) {
+		subcmd := cmd.Command[name]
 		subCommand = append(subCommand, b.generateCommand(fmt.Sprintf("%s_%s", ident, name), name, level+1, subcmd)...)
 	}
 	return subCommand
@@ -441,19 +531,21 @@ func (b *bashScriptBuilder) buildFlagDict(ident string, bs []bashast.Stmt, cmd *
 	typeDict = make(map[string]flagType)
 	for _, flag := range cmd.Flags {
 		flagIdent := fmt.Sprintf("%s_%s", ident, flag.Name)
-		switch flag.Type {
-		case "string":
-			typeDict[flagIdent] = flagTypeString
+		typeDict[flagIdent] = flagTypeOf(flag)
+		switch typeDict[flagIdent] {
+		case flagTypeString:
 			bs = append(bs, bashast.AssignStatement(bashast.Identifier(flagIdent), bashast.String("")))
-		case "bool":
-			typeDict[flagIdent] = flagTypeBool
+		case flagTypeBool:
 			bs = append(bs, bashast.AssignStatement(bashast.Identifier(flagIdent), bashast.FALSE))
+		case flagTypeList:
+			bs = append(bs, bashast.AssignStatement(bashast.Identifier(flagIdent), bashast.Raw("()")))
 		}
 	}
 	return typeDict, bs
 }
 
 func (b *bashScriptBuilder) buildBlockSmt(subCommand []bashast.Stmt, ident string, cmd *cfg.Command) (bs []bashast.Stmt) {
+	bs = b.buildEnvStmt(dotenvFiles(cmd.Dotenv), cmd.Env)
 	bs = b.buildArgsStmt(ident, subCommand, bs)
 
 	typeDict, bs := b.buildFlagDict(ident, bs, cmd)
@@ -467,29 +559,46 @@ func (b *bashScriptBuilder) buildBlockSmt(subCommand []bashast.Stmt, ident strin
 		)
 		bs = append(bs, forStmt)
 		forStmt.Body.Append(b.collectFlagStmt(ident, cmd))
+		bs = append(bs, b.buildFallbackStmt(ident, cmd)...)
+		bs = append(bs, b.buildCheckStmt(ident, cmd)...)
+		bs = append(bs, b.buildPositionalStmt(ident, cmd, typeDict)...)
+
+		bs = b.buildMatchStmt(ident, cmd, bs, typeDict)
+	} else if len(cmd.Args) > 0 {
+		bs = append(bs, bashast.RawStmt(`non_matched_args=("${args[@]}")`))
+		bs = append(bs, b.buildCheckStmt(ident, cmd)...)
+		bs = append(bs, b.buildPositionalStmt(ident, cmd, typeDict)...)
 
 		bs = b.buildMatchStmt(ident, cmd, bs, typeDict)
 	} else {
-		for _, matchCase := range cmd.Match {
-			matchCase.Run = indexResolver.apply(matchCase.Run, func(matched string) string {
-				i, _ := strconv.Atoi(matched)
-				i--
-				return fmt.Sprintf(`"$($args[%d])"`, i)
-			})
-			matchCase.Run = envResolver.apply(matchCase.Run, func(matched string) string {
-				return fmt.Sprintf("$env:%s", matched)
-			})
-			switch pattern := matchCase.Pattern.(type) {
-			case string:
-				if pattern == "_" || len(pattern) == 0 {
-					bs = append(bs, bashast.CallStatement(matchCase.Run), bashast.CallStatement("exit"))
-				}
-			}
-		}
+		bs = b.buildMatchStmt(ident, cmd, bs, typeDict)
 	}
 	return
 }
 
+// buildDirStmt changes into dir unless it's empty,
+// bash scripts run in their own process so the caller isn't affected.
+func (b *bashScriptBuilder) buildDirStmt(dir string) []bashast.Stmt {
+	if len(dir) == 0 {
+		return nil
+	}
+	return []bashast.Stmt{bashast.CallStatement("cd", bashQuote(dir))}
+}
+
+// buildEnvStmt loads the dotenv files and exports the variables of env,
+// the variables are set before the subcommands so that they inherit them.
+func (b *bashScriptBuilder) buildEnvStmt(dotenv []string, env map[string]string) []bashast.Stmt {
+	stmts := []bashast.Stmt{}
+	if len(dotenv) > 0 {
+		// the script exits when the files can't be read
+		stmts = append(stmts, bashast.RawStmt(fmt.Sprintf(`eval "$(%s || echo exit 1)"`, dotenvCommand(b.shell, dotenv, bashQuote))))
+	}
+	for _, k := range cfg.SortedKeys(env) {
+		stmts = append(stmts, bashast.RawStmt(fmt.Sprintf("export %s=%s", k, bashQuote(env[k]))))
+	}
+	return stmts
+}
+
 func (b *bashScriptBuilder) collectFlagStmt(ident string, cmd *cfg.Command) bashast.Stmt {
 	switchStmt := &bashast.SwitchStmt{
 		Cond: bashast.String("${args[i]}"),
@@ -516,12 +625,16 @@ func (b *bashScriptBuilder) collectFlagStmt(ident string, cmd *cfg.Command) bash
 		rule := strings.Join(alias, "|")
 		caseStmt := bashast.CaseStatement(bashast.Identifier(rule))
 		switchStmt.Cases = append(switchStmt.Cases, caseStmt)
-		switch flag.Type {
-		case "string":
+		switch flagTypeOf(flag) {
+		case flagTypeString:
 			caseStmt.Body.Append(
 				bashast.AssignStatement(bashast.Identifier(flagIdent), bashast.String("${args[i+1]}")),
-				bashast.RawStmt("((i++))"))
-		case "bool":
+				bashast.RawStmt("((++i))"))
+		case flagTypeList:
+			caseStmt.Body.Append(
+				bashast.RawStmt(fmt.Sprintf(`%s+=("${args[i+1]}")`, flagIdent)),
+				bashast.RawStmt("((++i))"))
+		case flagTypeBool:
 			caseStmt.Body.Append(bashast.AssignStatement(bashast.Identifier(flagIdent), bashast.TRUE))
 			// caseStmt.Body.List = append(caseStmt.Body.List, &psast.ExprStmt{
 			// 	X: &psast.IncDecExpr{
@@ -534,85 +647,245 @@ func (b *bashScriptBuilder) collectFlagStmt(ident string, cmd *cfg.Command) bash
 	return switchStmt
 }
 
-func (b *bashScriptBuilder) buildMatchStmt(ident string, cmd *cfg.Command, bs []bashast.Stmt, typeDict map[string]flagType) []bashast.Stmt {
-	type sortedMatchCase struct {
-		weight int
-		names  []string
-		body   string
+// buildFallbackStmt sets the flags which weren't passed on the command line
+// from their environment variable, and then from their default value.
+func (b *bashScriptBuilder) buildFallbackStmt(ident string, cmd *cfg.Command) []bashast.Stmt {
+	stmts := []bashast.Stmt{}
+	for _, flag := range cmd.Flags {
+		flagIdent := fmt.Sprintf("%s_%s", ident, flag.Name)
+		defaults := flag.Defaults()
+		switch flagTypeOf(flag) {
+		case flagTypeString:
+			if len(flag.Env) > 0 {
+				ifStmt := bashast.IfStatement()
+				ifStmt.Cond = bashast.Raw(fmt.Sprintf(`-z "$%s"`, flagIdent))
+				ifStmt.Body.Append(bashast.AssignStatement(bashast.Identifier(flagIdent), bashast.String("$"+flag.Env)))
+				stmts = append(stmts, ifStmt)
+			}
+			if len(defaults) > 0 {
+				ifStmt := bashast.IfStatement()
+				ifStmt.Cond = bashast.Raw(fmt.Sprintf(`-z "$%s"`, flagIdent))
+				ifStmt.Body.Append(bashast.AssignStatement(bashast.Identifier(flagIdent), bashast.Raw(bashQuote(defaults[0]))))
+				stmts = append(stmts, ifStmt)
+			}
+		case flagTypeList:
+			if len(flag.Env) > 0 {
+				ifStmt := bashast.IfStatement()
+				ifStmt.Cond = bashast.Raw(fmt.Sprintf(`${#%s[@]} -eq 0 && -n "$%s"`, flagIdent, flag.Env))
+				// zsh reads an array with -A
+				array := "-a"
+				if b.shell == "zsh" {
+					array = "-A"
+				}
+				ifStmt.Body.Append(bashast.RawStmt(fmt.Sprintf(`IFS=',' read -r %s %s <<< "$%s"`, array, flagIdent, flag.Env)))
+				stmts = append(stmts, ifStmt)
+			}
+			if len(defaults) > 0 {
+				aslices.MapInPlace(defaults, bashQuote)
+				ifStmt := bashast.IfStatement()
+				ifStmt.Cond = bashast.Raw(fmt.Sprintf(`${#%s[@]} -eq 0`, flagIdent))
+				ifStmt.Body.Append(bashast.AssignStatement(bashast.Identifier(flagIdent), bashast.Raw("("+strings.Join(defaults, " ")+")")))
+				stmts = append(stmts, ifStmt)
+			}
+		case flagTypeBool:
+			if len(flag.Env) > 0 {
+				ifStmt := bashast.IfStatement()
+				ifStmt.Cond = bashast.Raw(fmt.Sprintf(`$%s == false && "$%s" =~ ^(1|true)$`, flagIdent, flag.Env))
+				ifStmt.Body.Append(bashast.AssignStatement(bashast.Identifier(flagIdent), bashast.TRUE))
+				stmts = append(stmts, ifStmt)
+			}
+		}
+	}
+	return stmts
+}
+
+// buildCheckStmt validates the flags and positionals unless help is requested.
+func (b *bashScriptBuilder) buildCheckStmt(ident string, cmd *cfg.Command) []bashast.Stmt {
+	checks := []bashast.Stmt{}
+	for _, c := range checksOf(b, ident, cmd) {
+		ifStmt := bashast.IfStatement()
+		ifStmt.Cond = bashast.Raw(c.test)
+		ifStmt.Body.Append(b.buildErrorStmt(cmd, c.msg)...)
+		checks = append(checks, ifStmt)
+	}
+	if len(checks) == 0 || !hasHelpFlag(cmd) {
+		return checks
+	}
+	ifStmt := bashast.IfStatement()
+	ifStmt.Cond = bashast.BinaryExpression(bashast.RefRaw(ident+"_help"), bashtoken.EQ, bashast.FALSE)
+	ifStmt.Body.Append(checks...)
+	return []bashast.Stmt{ifStmt}
+}
+
+// buildPositionalStmt assigns the positionals left over by the flags to the
+// variables of the declared arguments, the variadic one takes the rest as an array.
+func (b *bashScriptBuilder) buildPositionalStmt(ident string, cmd *cfg.Command, typeDict map[string]flagType) []bashast.Stmt {
+	stmts := []bashast.Stmt{}
+	for i, arg := range cmd.Args {
+		argIdent := fmt.Sprintf("%s_%s", ident, arg.Name)
+		if arg.Variadic {
+			typeDict[argIdent] = flagTypeList
+			stmts = append(stmts, bashast.AssignStatement(bashast.Identifier(argIdent), bashast.Raw(fmt.Sprintf(`("${non_matched_args[@]:%d}")`, i))))
+		} else {
+			typeDict[argIdent] = flagTypeString
+			stmts = append(stmts, bashast.AssignStatement(bashast.Identifier(argIdent), bashast.String(fmt.Sprintf("${non_matched_args[%d]}", i))))
+		}
+	}
+	return stmts
+}
+
+func (b *bashScriptBuilder) platform() string { return b.shell }
+
+func (b *bashScriptBuilder) compile(e cond.Expr, resolve func(string) string) string {
+	return cond.Bash(e, resolve)
+}
+
+var bashEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
+
+func (b *bashScriptBuilder) escape(text string) string { return bashEscaper.Replace(text) }
+
+func (b *bashScriptBuilder) isEmpty(name string, typ flagType) string {
+	if typ == flagTypeList {
+		return fmt.Sprintf(`${#%s[@]} -eq 0`, name)
+	}
+	return fmt.Sprintf(`-z "$%s"`, name)
+}
+
+func (b *bashScriptBuilder) mismatches(name, pattern string) string {
+	return fmt.Sprintf(`-n "$%s" && ! "$%s" =~ %s`, name, name, bashRegex(pattern))
+}
+
+func (b *bashScriptBuilder) argCount() string { return "${#non_matched_args[@]}" }
+
+func (b *bashScriptBuilder) compareArgs(op string, n int) string {
+	return fmt.Sprintf("${#non_matched_args[@]} %s %d", op, n)
+}
+
+// buildErrorStmt prints the message and the help of the command to stderr and exits.
+func (b *bashScriptBuilder) buildErrorStmt(cmd *cfg.Command, msg string) []bashast.Stmt {
+	stmts := []bashast.Stmt{bashast.CallStatement("echo", fmt.Sprintf(`"%s" >&2`, msg))}
+	if help := cmd.HelpCmd(cmd.Name()); len(help) > 0 {
+		stmts = append(stmts, bashast.CallStatement("cat", fmt.Sprintf("<<EOF >&2\n%s\nEOF", help)))
+	}
+	return append(stmts, bashast.CallStatement("exit", "1"))
+}
+
+// bashTest compiles the test of a match pattern on the variable name,
+// which holds a value of type typ, into an expression of [[ ]].
+func bashTest(name string, typ flagType, t cfg.Test) bashast.Expr {
+	var test bashast.Expr
+	switch {
+	case len(t.Op) == 0 && typ == flagTypeList:
+		test = bashast.Raw(fmt.Sprintf(`${#%s[@]} -gt 0`, name))
+	case len(t.Op) == 0 && typ == flagTypeBool:
+		test = bashast.BinaryExpression(bashast.RefRaw(name), bashtoken.EQ, bashast.TRUE)
+	case len(t.Op) == 0:
+		test = bashast.Raw(fmt.Sprintf(`-n "$%s"`, name))
+	case t.Op == "==" && typ == flagTypeBool:
+		test = bashast.BinaryExpression(bashast.RefRaw(name), bashtoken.EQ, bashast.Bool(t.Values[0] == "true"))
+	case t.Op == "==":
+		test = bashast.Raw(fmt.Sprintf(`"$%s" == %s`, name, bashQuote(t.Values[0])))
+	case t.Op == "~":
+		test = bashast.Raw(fmt.Sprintf(`"$%s" =~ %s`, name, bashRegex(t.Values[0])))
+	case t.Op == "in":
+		values := []string{}
+		for _, v := range t.Values {
+			values = append(values, fmt.Sprintf(`"$%s" == %s`, name, bashQuote(v)))
+		}
+		test = bashast.Raw(fmt.Sprintf("( %s )", strings.Join(values, " || ")))
 	}
+	if t.Negate {
+		return bashast.Raw(fmt.Sprintf("! ( %s )", test))
+	}
+	return test
+}
 
-	match := []sortedMatchCase{}
-	var defaultMatchCase *sortedMatchCase
-	for _, matchCase := range cmd.Match {
-		if len(matchCase.Platform) > 0 && matchCase.Platform != "bash" {
-			continue
+func hasHelpFlag(cmd *cfg.Command) bool {
+	for _, flag := range cmd.Flags {
+		if flag.Name == "help" {
+			return true
 		}
-		names := []string{}
-		matchCase.Run = indexResolver.apply(matchCase.Run, func(matched string) string {
+	}
+	return false
+}
+
+func (b *bashScriptBuilder) buildMatchStmt(ident string, cmd *cfg.Command, bs []bashast.Stmt, typeDict map[string]flagType) []bashast.Stmt {
+	match, defaults, defaultMatchCase := matchCases(b, cmd, func(run string) string {
+		run = indexResolver.apply(run, func(matched string) string {
 			i, _ := strconv.Atoi(matched)
 			i--
 			return fmt.Sprintf(`"$($args[%d])"`, i)
 		})
-		matchCase.Run = namedResolver.apply(matchCase.Run, func(matched string) string {
+		run = namedResolver.apply(run, func(matched string) string {
 			// shellcheck: Double quote to prevent globbing and word splitting.
+			if typeDict[fmt.Sprintf("%s_%s", ident, matched)] == flagTypeList {
+				return fmt.Sprintf("${%s_%s[@]}", ident, matched)
+			}
 			return fmt.Sprintf("$%s_%s", ident, matched)
 		})
-		matchCase.Run = envResolver.apply(matchCase.Run, func(matched string) string {
+		return envResolver.apply(run, func(matched string) string {
 			return fmt.Sprintf("$%s", matched)
 		})
-		switch pattern := matchCase.Pattern.(type) {
-		case string:
-			if pattern == "_" || len(pattern) == 0 {
-				defaultMatchCase = &sortedMatchCase{
-					body: matchCase.Run,
-				}
-				continue
-			}
-			names = append(names, fmt.Sprintf("%s_%s", ident, pattern))
-		case []any:
-			for _, v := range pattern {
-				if v, ok := v.(string); ok {
-					names = append(names, fmt.Sprintf("%s_%s", ident, v))
-				}
-			}
+	})
+
+	defaultBody := func(c *sortedMatchCase) *bashast.BlockStmt {
+		body := bashast.BlockStatement(b.buildDirStmt(c.dir)...)
+		body.Append(bashast.RawStmt(c.body), bashast.CallStatement("exit"))
+		return body
+	}
+	var defaultStmt bashast.Stmt
+	if defaultMatchCase != nil {
+		defaultStmt = defaultBody(defaultMatchCase)
+	}
+	for i := len(defaults) - 1; i >= 0; i-- {
+		ifStmt := bashast.IfStatement()
+		ifStmt.Cond = bashast.Raw(defaults[i].when)
+		ifStmt.Body = defaultBody(&defaults[i])
+		if defaultStmt != nil {
+			ifStmt.Else = defaultStmt
 		}
-		if len(names) != 0 {
-			match = append(match, sortedMatchCase{weight: len(names), names: names, body: matchCase.Run})
+		defaultStmt = ifStmt
+	}
+	// the default case doesn't run when help is requested, so that the help gets printed
+	if defaultStmt != nil && hasHelpFlag(cmd) {
+		ifStmt := bashast.IfStatement()
+		ifStmt.Cond = bashast.BinaryExpression(bashast.RefRaw(ident+"_help"), bashtoken.EQ, bashast.FALSE)
+		if body, ok := defaultStmt.(*bashast.BlockStmt); ok {
+			ifStmt.Body = body
+		} else {
+			ifStmt.Body = bashast.BlockStatement(defaultStmt)
 		}
+		defaultStmt = ifStmt
 	}
 
 	if len(match) == 0 {
+		if ifStmt, ok := defaultStmt.(*bashast.IfStmt); ok {
+			bs = append(bs, ifStmt)
+		} else if defaultStmt != nil {
+			bs = append(bs, defaultStmt.(*bashast.BlockStmt).List...)
+		}
 		return bs
 	}
 
-	sort.Slice(match, func(i, j int) bool {
-		return match[i].weight > match[j].weight
-	})
-
 	matchStmt := bashast.IfStatement()
 	bs = append(bs, matchStmt)
 	for i, c := range match {
 		var cases bashast.Expr
-		for _, name := range c.names {
-			var cond bashast.Expr
-			switch typeDict[name] {
-			case flagTypeString:
-				cond = bashast.Raw(fmt.Sprintf(`-n "$%s"`, name))
-			case flagTypeBool:
-				cond = bashast.BinaryExpression(
-					bashast.RefRaw(name),
-					bashtoken.EQ,
-					bashast.TRUE,
-				)
-			}
+		for _, t := range c.tests {
+			name := fmt.Sprintf("%s_%s", ident, t.Name)
+			test := bashTest(name, typeDict[name], t)
 			if cases == nil {
-				cases = cond
+				cases = test
 			} else {
-				cases = bashast.BinaryExpression(cases, bashtoken.AND, cond)
+				cases = bashast.BinaryExpression(cases, bashtoken.AND, test)
 			}
 		}
+		if len(c.when) > 0 {
+			cases = bashast.BinaryExpression(cases, bashtoken.AND, bashast.Raw(c.when))
+		}
 
 		matchStmt.Cond = cases
+		matchStmt.Body.Append(b.buildDirStmt(c.dir)...)
 		if len(c.body) > 0 {
 			lines := strings.Split(c.body, "\n")
 			for i, line := range lines {
@@ -630,8 +903,8 @@ func (b *bashScriptBuilder) buildMatchStmt(ident string, cmd *cfg.Command, bs []
 		}
 	}
 
-	if defaultMatchCase != nil {
-		matchStmt.Else = bashast.BlockStatement(bashast.RawStmt(defaultMatchCase.body))
+	if defaultStmt != nil {
+		matchStmt.Else = defaultStmt
 	}
 	return bs
 }
@@ -739,7 +1012,7 @@ func (b *psScriptBuilder) buildBlockStmt4Command(subCommand []psast.Stmt, ident
 	bs := b.buildBlockSmt(subCommand, ident, cmd)
 	if !cmd.DisableHelp {
 		bs = append(bs,
-			psast.CallStatement(token.None, "Write-Host", psast.String(cmd.HelpCmd(cmd.Name()))),
+			psast.CallStatement(token.None, "Write-Host", psast.String(b.escape(cmd.HelpCmd(cmd.Name())))),
 			psast.CallStatement(token.None, "exit"))
 	}
 	return bs
@@ -747,7 +1020,8 @@ func (b *psScriptBuilder) buildBlockStmt4Command(subCommand []psast.Stmt, ident
 
 func (b *psScriptBuilder) collectSubCommand4Extend(ident string, level int, cmd *cfg.Command) []psast.Stmt {
 	subCommand := []psast.Stmt{}
-	for name, subcmd := range cmd.Command {
+	for _, name := range cfg.SortedKeys(cmd.Command) {
+		subcmd := cmd.Command[name]
 		log.Tracef("collectSubCommand4Extend.%s", name)
 		subcmd.SetName(name)
 		subCommand = append(subCommand, b.generateExtension(fmt.Sprintf("%s_%s", ident, name), name, level+1, subcmd)...)
@@ -757,7 +1031,8 @@ func (b *psScriptBuilder) collectSubCommand4Extend(ident string, level int, cmd
 
 func (b *psScriptBuilder) collectSubCommand4Command(ident string, level int, cmd *cfg.Command) []psast.Stmt {
 	subCommand := []psast.Stmt{}
-	for name, subcmd := range cmd.Command {
+	for _, name := range cfg.SortedKeys(cmd.Command) {
+		subcmd := cmd.Command[name]
 		subCommand = append(subCommand, b.generateCommand(fmt.Sprintf("%s_%s", ident, name), name, level+1, subcmd)...)
 	}
 	return subCommand
@@ -765,6 +1040,7 @@ func (b *psScriptBuilder) collectSubCommand4Command(ident string, level int, cmd
 
 func (b *psScriptBuilder) buildBlockSmt(subCommand []psast.Stmt, ident string, cmd *cfg.Command) (bs []psast.Stmt) {
 	log.Tracef("enter buildBlockSmt.%s", ident)
+	bs = b.buildEnvStmt(dotenvFiles(cmd.Dotenv), cmd.Env)
 	bs = b.buildArgsStmt(ident, subCommand, bs)
 
 	log.WithField("output", stmtString(bs)).Tracef("")
@@ -782,30 +1058,19 @@ func (b *psScriptBuilder) buildBlockSmt(subCommand []psast.Stmt, ident string, c
 		bs = append(bs, forStmt)
 
 		forStmt.Body.Append(b.collectFlagStmt(ident, cmd))
+		bs = append(bs, b.buildFallbackStmt(ident, cmd)...)
+		bs = append(bs, b.buildCheckStmt(ident, cmd)...)
+		bs = append(bs, b.buildPositionalStmt(ident, cmd, typeDict)...)
+
+		bs = b.buildMatchStmt(ident, cmd, bs, typeDict)
+	} else if len(cmd.Args) > 0 {
+		bs = append(bs, psast.AssignStatement(psast.RefRaw("non_matched_args"), psast.Raw("@($args)")))
+		bs = append(bs, b.buildCheckStmt(ident, cmd)...)
+		bs = append(bs, b.buildPositionalStmt(ident, cmd, typeDict)...)
 
 		bs = b.buildMatchStmt(ident, cmd, bs, typeDict)
 	} else {
-		for _, matchCase := range cmd.Match {
-			matchCase.Run = indexResolver.apply(matchCase.Run, func(matched string) string {
-				i, err := strconv.Atoi(matched)
-				if err != nil {
-					log.WithError(err).Fatal("evaluating value")
-				}
-				i--
-				return fmt.Sprintf(`"$($args[%d])"`, i)
-			})
-			matchCase.Run = envResolver.apply(matchCase.Run, func(matched string) string {
-				return fmt.Sprintf("$env:%s", matched)
-			})
-			switch pattern := matchCase.Pattern.(type) {
-			case string:
-				if pattern == "_" || len(pattern) == 0 {
-					bs = append(bs,
-						psast.CallStatement(token.None, matchCase.Run),
-						psast.CallStatement(token.None, "exit"))
-				}
-			}
-		}
+		bs = b.buildMatchStmt(ident, cmd, bs, typeDict)
 	}
 	log.Tracef("exit buildBlockSmt.%s", ident)
 	return
@@ -831,18 +1096,68 @@ func (b *psScriptBuilder) buildFlagDict(ident string, bs []psast.Stmt, cmd *cfg.
 	typeDict = make(map[string]flagType)
 	for _, flag := range cmd.Flags {
 		flagIdent := fmt.Sprintf("%s_%s", ident, flag.Name)
-		switch flag.Type {
-		case "string":
-			typeDict[flagIdent] = flagTypeString
+		typeDict[flagIdent] = flagTypeOf(flag)
+		switch typeDict[flagIdent] {
+		case flagTypeString:
 			stmts = append(stmts, psast.AssignStatement(psast.RefRaw(flagIdent), psast.NULL))
-		case "bool":
-			typeDict[flagIdent] = flagTypeBool
+		case flagTypeBool:
 			stmts = append(stmts, psast.AssignStatement(psast.RefRaw(flagIdent), psast.FALSE))
+		case flagTypeList:
+			stmts = append(stmts, psast.AssignStatement(psast.RefRaw(flagIdent), psast.Raw("@()")))
 		}
 	}
 	return
 }
 
+// buildCaseBody runs the statements in dir unless it's empty. Scripts share
+// the location of the calling session, so it's restored once they exit.
+func (b *psScriptBuilder) buildCaseBody(dir string, stmts ...psast.Stmt) []psast.Stmt {
+	if len(dir) == 0 {
+		return stmts
+	}
+	return []psast.Stmt{
+		psast.CallStatement(token.None, "Push-Location", psast.Raw(psQuote(dir))),
+		psast.TryStatement(
+			psast.BlockStatement(stmts...),
+			psast.BlockStatement(psast.CallStatement(token.None, "Pop-Location"))),
+	}
+}
+
+// isolate runs the script in dir, if any, and restores the environment of the session once it exits.
+func (b *psScriptBuilder) isolate(dir string) {
+	i := 0
+	for i < len(b.node.Stmts) {
+		if _, ok := b.node.Stmts[i].(*psast.Comment); !ok {
+			break
+		}
+		i++
+	}
+	head := append([]psast.Stmt{}, b.node.Stmts[:i]...)
+	head = append(head, psast.AssignStatement(psast.RefRaw("aliax_env"), psast.Raw("Get-ChildItem env:")))
+	finally := psast.BlockStatement(&psast.ExprStmt{X: psast.Raw(psRestoreEnv)})
+	if len(dir) > 0 {
+		head = append(head, psast.CallStatement(token.None, "Push-Location", psast.Raw(psQuote(dir))))
+		finally = psast.BlockStatement(psast.CallStatement(token.None, "Pop-Location"), &psast.ExprStmt{X: psast.Raw(psRestoreEnv)})
+	}
+	b.node.Stmts = append(head, psast.TryStatement(psast.BlockStatement(b.node.Stmts[i:]...), finally))
+}
+
+// psRestoreEnv restores the environment saved in $aliax_env.
+const psRestoreEnv = `Get-ChildItem env: | Where-Object { $aliax_env.Name -notcontains $_.Name } | ForEach-Object { Remove-Item -LiteralPath "env:$($_.Name)" }; $aliax_env | ForEach-Object { Set-Item -LiteralPath "env:$($_.Name)" -Value $_.Value }`
+
+// buildEnvStmt loads the dotenv files and sets the variables of env,
+// the variables are set before the subcommands so that they inherit them.
+func (b *psScriptBuilder) buildEnvStmt(dotenv []string, env map[string]string) []psast.Stmt {
+	stmts := []psast.Stmt{}
+	if len(dotenv) > 0 {
+		stmts = append(stmts, &psast.ExprStmt{X: psast.Raw(dotenvCommand("powershell", dotenv, psQuote) + " | Out-String | Invoke-Expression")})
+	}
+	for _, k := range cfg.SortedKeys(env) {
+		stmts = append(stmts, psast.AssignStatement(psast.Raw(fmt.Sprintf("${env:%s}", k)), psast.Raw(psQuote(env[k]))))
+	}
+	return stmts
+}
+
 func (b *psScriptBuilder) collectFlagStmt(ident string, cmd *cfg.Command) psast.Stmt {
 	switchStmt := &psast.SwitchStmt{
 		Mode: psast.MatchModeRegex,
@@ -870,15 +1185,24 @@ func (b *psScriptBuilder) collectFlagStmt(ident string, cmd *cfg.Command) psast.
 		rule := strings.Join(alias, "|")
 		caseStmt := psast.CaseStatement(psast.String(rule))
 		switchStmt.Cases = append(switchStmt.Cases, caseStmt)
-		switch flag.Type {
-		case "string":
+		switch flagTypeOf(flag) {
+		case flagTypeString:
 			caseStmt.Body.Append(
 				psast.AssignStatement(
 					psast.RefRaw(flagIdent),
 					psast.IndexExpression(
 						psast.RefRaw("args"), psast.BinaryExpression(psast.RefRaw("i"), token.ADD, psast.Number(1)))))
 			caseStmt.Body.Append(&psast.ExprStmt{X: psast.IncDecExpression("i", true)})
-		case "bool":
+		case flagTypeList:
+			caseStmt.Body.Append(&psast.ExprStmt{
+				X: psast.BinaryExpression(
+					psast.RefRaw(flagIdent),
+					token.ADD_ASSIGN,
+					psast.IndexExpression(
+						psast.RefRaw("args"), psast.BinaryExpression(psast.RefRaw("i"), token.ADD, psast.Number(1)))),
+			})
+			caseStmt.Body.Append(&psast.ExprStmt{X: psast.IncDecExpression("i", true)})
+		case flagTypeBool:
 			caseStmt.Body.Append(psast.AssignStatement(psast.RefRaw(flagIdent), psast.TRUE))
 			// caseStmt.Body.List = append(caseStmt.Body.List, &psast.ExprStmt{
 			// 	X: &psast.IncDecExpr{
@@ -891,91 +1215,244 @@ func (b *psScriptBuilder) collectFlagStmt(ident string, cmd *cfg.Command) psast.
 	return switchStmt
 }
 
-func (b *psScriptBuilder) buildMatchStmt(ident string, cmd *cfg.Command, bs []psast.Stmt, typeDict map[string]flagType) []psast.Stmt {
-	match := []sortedMatchCase{}
-	var defaultMatchCase *sortedMatchCase
-	for _, matchCase := range cmd.Match {
-		if len(matchCase.Platform) > 0 && matchCase.Platform != "powershell" {
-			continue
+// buildFallbackStmt sets the flags which weren't passed on the command line
+// from their environment variable, and then from their default value.
+func (b *psScriptBuilder) buildFallbackStmt(ident string, cmd *cfg.Command) []psast.Stmt {
+	stmts := []psast.Stmt{}
+	for _, flag := range cmd.Flags {
+		flagIdent := fmt.Sprintf("%s_%s", ident, flag.Name)
+		defaults := flag.Defaults()
+		switch flagTypeOf(flag) {
+		case flagTypeString:
+			if len(flag.Env) > 0 {
+				ifStmt := psast.IfStatement()
+				ifStmt.Cond = psast.Raw("-not $" + flagIdent)
+				ifStmt.Body.Append(psast.AssignStatement(psast.RefRaw(flagIdent), psast.Raw("$env:"+flag.Env)))
+				stmts = append(stmts, ifStmt)
+			}
+			if len(defaults) > 0 {
+				ifStmt := psast.IfStatement()
+				ifStmt.Cond = psast.Raw("-not $" + flagIdent)
+				ifStmt.Body.Append(psast.AssignStatement(psast.RefRaw(flagIdent), psast.Raw(psQuote(defaults[0]))))
+				stmts = append(stmts, ifStmt)
+			}
+		case flagTypeList:
+			if len(flag.Env) > 0 {
+				ifStmt := psast.IfStatement()
+				ifStmt.Cond = psast.Raw(fmt.Sprintf("$%s.Count -eq 0 -and $env:%s", flagIdent, flag.Env))
+				ifStmt.Body.Append(psast.AssignStatement(psast.RefRaw(flagIdent), psast.Raw(fmt.Sprintf("@($env:%s -split ',')", flag.Env))))
+				stmts = append(stmts, ifStmt)
+			}
+			if len(defaults) > 0 {
+				aslices.MapInPlace(defaults, psQuote)
+				ifStmt := psast.IfStatement()
+				ifStmt.Cond = psast.Raw(fmt.Sprintf("$%s.Count -eq 0", flagIdent))
+				ifStmt.Body.Append(psast.AssignStatement(psast.RefRaw(flagIdent), psast.Raw("@("+strings.Join(defaults, ", ")+")")))
+				stmts = append(stmts, ifStmt)
+			}
+		case flagTypeBool:
+			if len(flag.Env) > 0 {
+				ifStmt := psast.IfStatement()
+				ifStmt.Cond = psast.Raw(fmt.Sprintf("-not $%s -and $env:%s -match '^(1|true)$'", flagIdent, flag.Env))
+				ifStmt.Body.Append(psast.AssignStatement(psast.RefRaw(flagIdent), psast.TRUE))
+				stmts = append(stmts, ifStmt)
+			}
+		}
+	}
+	return stmts
+}
+
+// buildCheckStmt validates the flags and positionals unless help is requested.
+func (b *psScriptBuilder) buildCheckStmt(ident string, cmd *cfg.Command) []psast.Stmt {
+	checks := []psast.Stmt{}
+	for _, c := range checksOf(b, ident, cmd) {
+		ifStmt := psast.IfStatement()
+		ifStmt.Cond = psast.Raw(c.test)
+		ifStmt.Body.Append(b.buildErrorStmt(cmd, c.msg)...)
+		checks = append(checks, ifStmt)
+	}
+	if len(checks) == 0 || !hasHelpFlag(cmd) {
+		return checks
+	}
+	ifStmt := psast.IfStatement()
+	ifStmt.Cond = psast.BinaryExpression(psast.RefRaw(ident+"_help"), token.EQ, psast.FALSE)
+	ifStmt.Body.Append(checks...)
+	return []psast.Stmt{ifStmt}
+}
+
+// buildPositionalStmt assigns the positionals left over by the flags to the
+// variables of the declared arguments, the variadic one takes the rest as an array.
+func (b *psScriptBuilder) buildPositionalStmt(ident string, cmd *cfg.Command, typeDict map[string]flagType) []psast.Stmt {
+	stmts := []psast.Stmt{}
+	for i, arg := range cmd.Args {
+		argIdent := fmt.Sprintf("%s_%s", ident, arg.Name)
+		if arg.Variadic {
+			typeDict[argIdent] = flagTypeList
+			stmts = append(stmts, psast.AssignStatement(psast.RefRaw(argIdent), psast.Raw(fmt.Sprintf("@($non_matched_args | Select-Object -Skip %d)", i))))
+		} else {
+			typeDict[argIdent] = flagTypeString
+			stmts = append(stmts, psast.AssignStatement(psast.RefRaw(argIdent), psast.IndexExpression(psast.RefRaw("non_matched_args"), psast.Number(i))))
+		}
+	}
+	return stmts
+}
+
+func (b *psScriptBuilder) platform() string { return "powershell" }
+
+func (b *psScriptBuilder) compile(e cond.Expr, resolve func(string) string) string {
+	return cond.PowerShell(e, resolve)
+}
+
+var psEscaper = strings.NewReplacer("`", "``", `"`, "`\"", `$`, "`$")
+
+func (b *psScriptBuilder) escape(text string) string { return psEscaper.Replace(text) }
+
+func (b *psScriptBuilder) isEmpty(name string, typ flagType) string {
+	if typ == flagTypeList {
+		return fmt.Sprintf("$%s.Count -eq 0", name)
+	}
+	return "-not $" + name
+}
+
+func (b *psScriptBuilder) mismatches(name, pattern string) string {
+	return fmt.Sprintf("$null -ne $%s -and $%s -notmatch %s", name, name, psQuote(pattern))
+}
+
+func (b *psScriptBuilder) argCount() string { return "$($non_matched_args.Count)" }
+
+func (b *psScriptBuilder) compareArgs(op string, n int) string {
+	return fmt.Sprintf("$non_matched_args.Count %s %d", op, n)
+}
+
+// buildErrorStmt prints the message and the help of the command and exits.
+func (b *psScriptBuilder) buildErrorStmt(cmd *cfg.Command, msg string) []psast.Stmt {
+	stmts := []psast.Stmt{psast.CallStatement(token.None, "Write-Host", psast.String(msg))}
+	if help := cmd.HelpCmd(cmd.Name()); len(help) > 0 {
+		stmts = append(stmts, psast.CallStatement(token.None, "Write-Host", psast.String(b.escape(help))))
+	}
+	return append(stmts, psast.CallStatement(token.None, "exit", psast.Number(1)))
+}
+
+// psTest is the PowerShell version of bashTest.
+func psTest(name string, typ flagType, t cfg.Test) psast.Expr {
+	var test psast.Expr
+	switch {
+	case len(t.Op) == 0 && typ == flagTypeList:
+		test = psast.BinaryExpression(
+			psast.SelectorExpression(psast.RefRaw(name), psast.Identifier("Count")),
+			token.GT,
+			psast.Number(0),
+		)
+	case len(t.Op) == 0 && typ == flagTypeBool:
+		test = psast.BinaryExpression(psast.RefRaw(name), token.NE, psast.FALSE)
+	case len(t.Op) == 0:
+		test = psast.BinaryExpression(psast.NULL, token.NE, psast.RefRaw(name))
+	case t.Op == "==" && typ == flagTypeBool:
+		test = psast.Raw(fmt.Sprintf("$%s -eq $%s", name, t.Values[0]))
+	case t.Op == "==":
+		test = psast.Raw(fmt.Sprintf("$%s -ceq %s", name, psQuote(t.Values[0])))
+	case t.Op == "~":
+		test = psast.Raw(fmt.Sprintf("$%s -cmatch %s", name, psQuote(t.Values[0])))
+	case t.Op == "in":
+		values := []string{}
+		for _, v := range t.Values {
+			values = append(values, psQuote(v))
 		}
-		names := []string{}
-		matchCase.Run = indexResolver.apply(matchCase.Run, func(matched string) string {
+		test = psast.Raw(fmt.Sprintf("@(%s) -ccontains $%s", strings.Join(values, ", "), name))
+	}
+	if t.Negate {
+		return psast.Raw(fmt.Sprintf("-not (%s)", test))
+	}
+	return test
+}
+
+// psQuote returns s as a single-quoted PowerShell string.
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func (b *psScriptBuilder) buildMatchStmt(ident string, cmd *cfg.Command, bs []psast.Stmt, typeDict map[string]flagType) []psast.Stmt {
+	match, defaults, defaultMatchCase := matchCases(b, cmd, func(run string) string {
+		run = indexResolver.apply(run, func(matched string) string {
 			i, _ := strconv.Atoi(matched)
 			i--
 			return fmt.Sprintf(`"$($args[%d])"`, i)
 		})
-		matchCase.Run = namedResolver.apply(matchCase.Run, func(matched string) string {
+		run = namedResolver.apply(run, func(matched string) string {
 			return fmt.Sprintf("$%s_%s", ident, matched)
 		})
-		matchCase.Run = envResolver.apply(matchCase.Run, func(matched string) string {
+		return envResolver.apply(run, func(matched string) string {
 			return fmt.Sprintf("$env:%s", matched)
 		})
-		switch pattern := matchCase.Pattern.(type) {
-		case string:
-			if pattern == "_" || len(pattern) == 0 {
-				defaultMatchCase = &sortedMatchCase{
-					body: matchCase.Run,
-				}
-				continue
-			}
-			names = append(names, fmt.Sprintf("%s_%s", ident, pattern))
-		case []any:
-			for _, v := range pattern {
-				if v, ok := v.(string); ok {
-					names = append(names, fmt.Sprintf("%s_%s", ident, v))
-				}
-			}
+	})
+	defaultBody := func(c *sortedMatchCase) *psast.BlockStmt {
+		return psast.BlockStatement(b.buildCaseBody(c.dir,
+			&psast.ExprStmt{X: psast.Identifier(c.body)},
+			psast.CallStatement(token.None, "exit"))...)
+	}
+	var defaultStmt psast.Stmt
+	if defaultMatchCase != nil {
+		defaultStmt = defaultBody(defaultMatchCase)
+	}
+	for i := len(defaults) - 1; i >= 0; i-- {
+		ifStmt := psast.IfStatement()
+		ifStmt.Cond = psast.Raw(defaults[i].when)
+		ifStmt.Body = defaultBody(&defaults[i])
+		if defaultStmt != nil {
+			ifStmt.Else = defaultStmt
 		}
-		if len(names) != 0 {
-			match = append(match, sortedMatchCase{weight: len(names), names: names, body: matchCase.Run})
+		defaultStmt = ifStmt
+	}
+	// the default case doesn't run when help is requested, so that the help gets printed
+	if defaultStmt != nil && hasHelpFlag(cmd) {
+		ifStmt := psast.IfStatement()
+		ifStmt.Cond = psast.BinaryExpression(psast.RefRaw(ident+"_help"), token.EQ, psast.FALSE)
+		if body, ok := defaultStmt.(*psast.BlockStmt); ok {
+			ifStmt.Body = body
+		} else {
+			ifStmt.Body = psast.BlockStatement(defaultStmt)
 		}
+		defaultStmt = ifStmt
 	}
+
 	if len(match) == 0 {
+		if ifStmt, ok := defaultStmt.(*psast.IfStmt); ok {
+			bs = append(bs, ifStmt)
+		} else if defaultStmt != nil {
+			bs = append(bs, defaultStmt.(*psast.BlockStmt).List...)
+		}
 		return bs
 	}
 
-	sort.Slice(match, func(i, j int) bool {
-		return match[i].weight > match[j].weight
-	})
-
 	matchStmt := psast.IfStatement()
 	bs = append(bs, matchStmt)
 	for i, c := range match {
 		var cases psast.Expr
-		for _, name := range c.names {
-			var cond psast.Expr
-			switch typeDict[name] {
-			case flagTypeString:
-				cond = psast.BinaryExpression(
-					psast.NULL,
-					token.NE,
-					psast.RefRaw(name),
-				)
-			case flagTypeBool:
-				cond = psast.BinaryExpression(
-					psast.RefRaw(name),
-					token.NE,
-					psast.FALSE,
-				)
-			}
-
+		for _, t := range c.tests {
+			name := fmt.Sprintf("%s_%s", ident, t.Name)
+			test := psTest(name, typeDict[name], t)
 			if cases == nil {
-				cases = cond
+				cases = test
 			} else {
-				cases = psast.BinaryExpression(cases, token.AND, cond)
+				cases = psast.BinaryExpression(cases, token.AND, test)
 			}
 		}
+		if len(c.when) > 0 {
+			cases = psast.BinaryExpression(cases, token.AND, psast.Raw(c.when))
+		}
 		matchStmt.Cond = cases
+		body := []psast.Stmt{}
 		if len(c.body) > 0 {
 			lines := strings.Split(c.body, "\n")
 			for i, line := range lines {
 				if i == len(lines)-1 && len(line) == 0 {
 					continue
 				}
-				matchStmt.Body.Append(psast.CallStatement(token.None, line))
+				body = append(body, psast.CallStatement(token.None, line))
 			}
 		}
-		matchStmt.Body.Append(psast.CallStatement(token.None, "exit"))
+		body = append(body, psast.CallStatement(token.None, "exit"))
+		matchStmt.Body.Append(b.buildCaseBody(c.dir, body...)...)
 		if i != len(match)-1 {
 			ifstmt := psast.IfStatement()
 			matchStmt.Else = ifstmt
@@ -983,12 +1460,8 @@ func (b *psScriptBuilder) buildMatchStmt(ident string, cmd *cfg.Command, bs []ps
 		}
 	}
 
-	if defaultMatchCase != nil {
-		matchStmt.Else = &psast.BlockStmt{
-			List: []psast.Stmt{
-				&psast.ExprStmt{X: psast.Identifier(defaultMatchCase.body)},
-			},
-		}
+	if defaultStmt != nil {
+		matchStmt.Else = defaultStmt
 	}
 
 	return bs
@@ -1004,7 +1477,8 @@ func init() {
 	initCmd.PersistentFlags().BoolVarP(&initParameter.force, "force", "f", false, "Force the initialization, bypassing confirmation prompts")
 	initCmd.PersistentFlags().BoolVarP(&initParameter.verbose, "verbose", "v", false, "Enable verbose output")
 	initCmd.PersistentFlags().StringVarP(&initParameter.template, "template", "t", "", "Specify a template to use for initialization")
-	initCmd.PersistentFlags().BoolVarP(&initParameter.save, "save", "s", false, "Backup the current executed YAML to the template directory")
+	initCmd.PersistentFlags().BoolVarP(&initParameter.save, "save", "s", false, "Backup the current executed configuration to the template directory")
+	initCmd.PersistentFlags().BoolVarP(&initParameter.all, "all", "a", false, "Generate scripts for every configuration used by aliax.work")
 }
 
 var (
@@ -1033,13 +1507,76 @@ func (r *resolver) apply(raw string, hd func(matched string) string) string {
 	})
 }
 
-type sortedMatchCase struct {
-	weight int
-	names  []string
-	body   string
+// renderEnv renders the templates of the top-level env map
+// and of the env maps of every command and extension.
+func renderEnv(file *cfg.Aliax) error {
+	render := func(path string, env map[string]string) error {
+		for k, v := range env {
+			buf := &strings.Builder{}
+			if err := template.Execute(buf, v, file.Variable); err != nil {
+				return fmt.Errorf("%s.%s: %w", path, k, err)
+			}
+			env[k] = buf.String()
+		}
+		return nil
+	}
+	var walk func(path string, cmds map[string]*cfg.Command) error
+	walk = func(path string, cmds map[string]*cfg.Command) error {
+		for name, cmd := range cmds {
+			if cmd == nil {
+				continue
+			}
+			if err := render(path+"."+name+".env", cmd.Env); err != nil {
+				return err
+			}
+			if err := walk(path+"."+name+".command", cmd.Command); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := render("env", file.Env); err != nil {
+		return err
+	}
+	if err := walk("extend", file.Extend); err != nil {
+		return err
+	}
+	return walk("command", file.Command)
+}
+
+// dotenvFiles resolves the dotenv files against the workspace root,
+// the generated scripts can run from any directory.
+func dotenvFiles(files []string) []string {
+	resolved := []string{}
+	for _, name := range files {
+		resolved = append(resolved, workspacePath(name))
+	}
+	return resolved
+}
+
+// workspacePath resolves name against the workspace root,
+// an empty name stays empty.
+func workspacePath(name string) string {
+	if len(name) == 0 || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(cfg.Root(), name)
 }
 
-func setGlobal() error {
+// runPath returns the directory holding the generated scripts,
+// relative paths are resolved against the workspace root.
+func runPath(file *cfg.Aliax) string {
+	path := file.RunPath
+	if len(path) == 0 {
+		path = "run-scripts"
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cfg.Root(), path)
+	}
+	return path
+}
+
+func setGlobal(path string) error {
 	var (
 		value string
 		err   error
@@ -1052,11 +1589,6 @@ func setGlobal() error {
 	if err != nil {
 		return err
 	}
-	path, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-	path = filepath.Join(path, "run-scripts")
 	if !strings.Contains(value, path) {
 		var output []byte
 		if runtime.GOOS == "windows" {
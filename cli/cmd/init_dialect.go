@@ -0,0 +1,204 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/cfg"
+	"aliax/internal/cond"
+	"aliax/internal/log"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// dialect is implemented by the script builders for the logic they share.
+type dialect interface {
+	// platform is the shell the match cases are selected for.
+	platform() string
+	// compile compiles a condition into a native test, see package cond.
+	compile(e cond.Expr, resolve func(string) string) string
+	// escape escapes text for a double-quoted string, so that only the
+	// references to the variables of the script placed around it expand.
+	escape(text string) string
+	// isEmpty tests whether the variable name of type typ holds no value.
+	isEmpty(name string, typ flagType) string
+	// mismatches tests whether the string variable name holds a value
+	// the regular expression doesn't match.
+	mismatches(name, pattern string) string
+	// argCount refers to the number of positionals in a double-quoted string,
+	// and compareArgs compares it with n using the -lt or -gt operator.
+	argCount() string
+	compareArgs(op string, n int) string
+}
+
+type flagType uint8
+
+const (
+	flagTypeString flagType = iota
+	flagTypeBool
+	flagTypeList
+)
+
+// flagTypeOf maps the type of a flag to how it's stored in the generated scripts,
+// every type holding a single value is stored as a string and validated afterwards.
+func flagTypeOf(flag cfg.Flag) flagType {
+	switch flag.Type {
+	case "bool":
+		return flagTypeBool
+	case "list":
+		return flagTypeList
+	}
+	return flagTypeString
+}
+
+// check is a validation of the generated scripts, msg is printed
+// along with the help of the command when test holds.
+type check struct {
+	test string
+	msg  string
+}
+
+// checksOf returns the checks of the flags and positionals of cmd.
+func checksOf(d dialect, ident string, cmd *cfg.Command) []check {
+	checks := []check{}
+	for _, flag := range cmd.Flags {
+		if flag.Required {
+			flagIdent := fmt.Sprintf("%s_%s", ident, flag.Name)
+			checks = append(checks, check{d.isEmpty(flagIdent, flagTypeOf(flag)), requiredFlagMessage(d, flag)})
+		}
+	}
+	for _, flag := range cmd.Flags {
+		pattern := flag.Pattern()
+		if len(pattern) == 0 || flagTypeOf(flag) != flagTypeString {
+			continue
+		}
+		flagIdent := fmt.Sprintf("%s_%s", ident, flag.Name)
+		checks = append(checks, check{d.mismatches(flagIdent, pattern), invalidFlagMessage(d, flag, "$"+flagIdent)})
+	}
+	if len(cmd.Args) > 0 {
+		min, max := cmd.ArgsRange()
+		if min > 0 {
+			checks = append(checks, check{d.compareArgs("-lt", min), tooFewArgsMessage(d, min)})
+		}
+		if max >= 0 {
+			checks = append(checks, check{d.compareArgs("-gt", max), tooManyArgsMessage(d, max)})
+		}
+	}
+	return checks
+}
+
+// flagNames returns how a flag is named in messages.
+func flagNames(flag cfg.Flag) string {
+	if len(flag.Alias) > 0 {
+		return strings.Join(flag.Alias, ", ")
+	}
+	return flag.Name
+}
+
+// invalidFlagMessage returns the error printed when the value of a flag is invalid,
+// ref is how the generated script refers to the value.
+func invalidFlagMessage(d dialect, flag cfg.Flag, ref string) string {
+	return d.escape("invalid value '") + ref +
+		d.escape(fmt.Sprintf("' for flag %s: expected %s", flagNames(flag), flag.Expected()))
+}
+
+// requiredFlagMessage returns the error printed when a required flag is missing.
+func requiredFlagMessage(d dialect, flag cfg.Flag) string {
+	if len(flag.Env) > 0 {
+		return d.escape(fmt.Sprintf("required flag %s not set, pass it or set %s", flagNames(flag), flag.Env))
+	}
+	return d.escape(fmt.Sprintf("required flag %s not set", flagNames(flag)))
+}
+
+// tooFewArgsMessage and tooManyArgsMessage return the errors printed when the
+// number of positionals is out of range.
+func tooFewArgsMessage(d dialect, min int) string {
+	return d.escape(fmt.Sprintf("requires at least %d arg(s), only received ", min)) + d.argCount()
+}
+
+func tooManyArgsMessage(d dialect, max int) string {
+	return d.escape(fmt.Sprintf("accepts at most %d arg(s), received ", max)) + d.argCount()
+}
+
+type sortedMatchCase struct {
+	// rank is the key of the case returned by cfg.Command.Rank.
+	rank  [2]int
+	tests []cfg.Test
+	body  string
+	// dir is the absolute directory the case runs in, if any.
+	dir string
+	// when is the condition of the case compiled for the shell, if any.
+	when string
+}
+
+// matchCases returns the sorted cases with tests, the default cases with
+// a condition and the one without, their commands rewritten by resolve.
+func matchCases(d dialect, cmd *cfg.Command, resolve func(string) string) (match, defaults []sortedMatchCase, def *sortedMatchCase) {
+	// the zsh scripts run the bash cases unless some are written for zsh
+	fallback := d.platform() == "zsh"
+	for _, c := range cmd.Match {
+		if c.Platform == "zsh" {
+			fallback = false
+		}
+	}
+	for _, matchCase := range cmd.Match {
+		if fallback && matchCase.Platform == "bash" {
+			matchCase.Platform = ""
+		}
+		when, ok := caseWhen(d, matchCase)
+		if !ok {
+			continue
+		}
+		tests, err := matchCase.Tests()
+		if err != nil {
+			log.WithError(err).Fatal("invalid match pattern")
+		}
+		c := sortedMatchCase{
+			rank:  cmd.Rank(matchCase),
+			tests: tests,
+			body:  resolve(matchCase.Run),
+			dir:   workspacePath(matchCase.Dir),
+			when:  when,
+		}
+		switch {
+		case len(tests) > 0:
+			match = append(match, c)
+		case len(when) > 0:
+			defaults = append(defaults, c)
+		default:
+			def = &c
+		}
+	}
+	sortMatchCases(match)
+	return match, defaults, def
+}
+
+// sortMatchCases sorts the cases in the order they're tried, the cases
+// of the same rank keep the order they're declared in.
+func sortMatchCases(match []sortedMatchCase) {
+	sort.SliceStable(match, func(i, j int) bool {
+		x, y := match[i].rank, match[j].rank
+		return x[0] > y[0] || x[0] == y[0] && x[1] > y[1]
+	})
+}
+
+// caseWhen compiles the condition of the case, ok is false when it never applies.
+// The cases of the other platforms are skipped, their commands are another language.
+func caseWhen(d dialect, c cfg.Case) (test string, ok bool) {
+	if len(c.Platform) > 0 && c.Platform != d.platform() {
+		return "", false
+	}
+	if len(c.When) == 0 {
+		return "", true
+	}
+	e, err := cond.Parse(c.When)
+	if err != nil {
+		log.WithError(err).WithField("when", c.When).Fatal("invalid condition")
+	}
+	e = cond.Simplify(e, &cond.Facts{Shell: d.platform()})
+	if v, ok := e.(cond.Const); ok {
+		return "", bool(v)
+	}
+	return d.compile(e, workspacePath), true
+}
@@ -0,0 +1,593 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/aos"
+	fishast "aliax/internal/ast/fish"
+	"aliax/internal/cfg"
+	"aliax/internal/cond"
+	"aliax/internal/log"
+	fishtoken "aliax/internal/token/fish"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// fishScriptBuilder generates a fish function for a command or an extension.
+// Unlike the bash scripts, the functions run in the shell of the caller, so
+// that the changes the match cases make to its environment are kept.
+// The function of name is written to fish/name.fish, where fish autoloads it
+// from once the directory is added to $fish_function_path.
+type fishScriptBuilder struct {
+	file  *os.File
+	cmd   *cfg.Command
+	node  *fishast.File
+	ident string
+	// root is the directory the cases run in when they don't set one.
+	root string
+}
+
+func newFishScriptBuilder(dir, name string, cmd *cfg.Command) (*fishScriptBuilder, error) {
+	filename := filepath.Join(dir, "fish", name+".fish")
+
+	fp, err := aos.Create(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) && !initParameter.force {
+			log.WithError(err).
+				WithField("file", filename).
+				WithField("suggestion", suggestionCleanWorkspace).
+				Fatal("file already exist")
+		} else {
+			return nil, err
+		}
+	}
+
+	builder := &fishScriptBuilder{
+		file:  fp,
+		node:  &fishast.File{},
+		cmd:   cmd,
+		ident: name,
+	}
+	builder.node.Append(fishast.Docs(copyright))
+	return builder, nil
+}
+
+func (b *fishScriptBuilder) close() error {
+	return b.file.Close()
+}
+
+// function returns the definition of the function named after the command.
+func (b *fishScriptBuilder) function(name string, cmd *cfg.Command) *fishast.FuncStmt {
+	options := []string{}
+	if len(cmd.Short) > 0 {
+		options = append(options, "--description", fishast.Quote(cmd.Short))
+	}
+	return fishast.FuncStatement(name, options...)
+}
+
+func (s *runScriptsBuilder) generateFishExtension(dir, name string, cmd *cfg.Command) (*fishScriptBuilder, error) {
+	fishBuilder, err := newFishScriptBuilder(dir, name, cmd)
+	if err != nil {
+		log.WithError(err).Error("creating fish builder")
+		return nil, err
+	}
+	defer fishBuilder.close()
+	fishBuilder.root = s.root
+	fn := fishBuilder.function(name, cmd)
+	// argparse consumes the flags of argv, the executable gets them all.
+	// The executable runs through command, the function may shadow it.
+	fn.Body.Append(fishast.SetStatement("-l", "aliax_args", fishast.RefRaw("argv")))
+	fn.Body.Append(fishBuilder.buildEnvStmt(s.dotenv, s.env)...)
+	fn.Body.Append(fishast.SetStatement("-l", executable, fishast.String(cmd.Bin)))
+	fn.Body.Append(fishBuilder.generateExtension(name, name, 0, cmd)...)
+	fn.Body.Append(fishBuilder.buildCaseBody("", fishast.RawStmt(fmt.Sprintf("command $%s $aliax_args", executable)))...)
+	fishBuilder.node.Append(fn)
+
+	fishast.Print(fishBuilder.node, fishBuilder.file)
+	return fishBuilder, nil
+}
+
+func (s *runScriptsBuilder) generateFishCommand(dir, name string, cmd *cfg.Command) (*fishScriptBuilder, error) {
+	fishBuilder, err := newFishScriptBuilder(dir, name, cmd)
+	if err != nil {
+		log.WithError(err).Error("creating fish builder")
+		return nil, err
+	}
+	defer fishBuilder.close()
+	fishBuilder.root = s.root
+	fn := fishBuilder.function(name, cmd)
+	fn.Body.Append(fishBuilder.buildEnvStmt(s.dotenv, s.env)...)
+	fn.Body.Append(fishBuilder.generateCommand(name, name, 0, cmd)...)
+	fishBuilder.node.Append(fn)
+
+	fishast.Print(fishBuilder.node, fishBuilder.file)
+	return fishBuilder, nil
+}
+
+func (b *fishScriptBuilder) generateExtension(ident, cmdName string, level int, cmd *cfg.Command) []fishast.Stmt {
+	subCommand := []fishast.Stmt{}
+	for _, name := range cfg.SortedKeys(cmd.Command) {
+		subcmd := cmd.Command[name]
+		subcmd.SetName(name)
+		subCommand = append(subCommand, b.generateExtension(fmt.Sprintf("%s_%s", ident, name), name, level+1, subcmd)...)
+	}
+	if level > 0 {
+		return b.buildSubCommandStmt(cmdName, b.buildBlockStmt(subCommand, ident, cmd))
+	}
+	return b.buildBlockStmt(subCommand, ident, cmd)
+}
+
+func (b *fishScriptBuilder) generateCommand(ident, cmdName string, level int, cmd *cfg.Command) []fishast.Stmt {
+	if level == 0 {
+		cmd.SetName(cmdName)
+	} else {
+		cmd.SetName(strings.Join(strings.Split(ident, "_"), " "))
+	}
+	subCommand := []fishast.Stmt{}
+	for _, name := range cfg.SortedKeys(cmd.Command) {
+		subCommand = append(subCommand, b.generateCommand(fmt.Sprintf("%s_%s", ident, name), name, level+1, cmd.Command[name])...)
+	}
+	bs := b.buildBlockStmt(subCommand, ident, cmd)
+	if !cmd.DisableHelp {
+		bs = append(bs, b.buildHelpStmt(cmd, "")...)
+		bs = append(bs, fishast.CallStatement("return"))
+	}
+	if level > 0 {
+		return b.buildSubCommandStmt(cmdName, bs)
+	}
+	return bs
+}
+
+// buildSubCommandStmt runs body when the first argument names the subcommand,
+// argv is shifted in the scope of the if block so that the next checks see it whole.
+func (b *fishScriptBuilder) buildSubCommandStmt(cmdName string, body []fishast.Stmt) []fishast.Stmt {
+	ifStmt := fishast.IfStatement()
+	ifStmt.Cond = fishast.BinaryExpression(
+		fishast.Raw("set -q argv[1]"),
+		fishtoken.AND,
+		fishast.TestExpression(fishast.QuotedRef("argv[1]"), fishtoken.EQ, fishast.String(cmdName)),
+	)
+	ifStmt.Body.Append(
+		fishast.SetStatement("-l", "argv", fishast.RefRaw("argv")),
+		fishast.CallStatement("set", "-e", "argv[1]"))
+	ifStmt.Body.Append(body...)
+	return []fishast.Stmt{ifStmt}
+}
+
+func (b *fishScriptBuilder) buildBlockStmt(subCommand []fishast.Stmt, ident string, cmd *cfg.Command) (bs []fishast.Stmt) {
+	bs = b.buildEnvStmt(dotenvFiles(cmd.Dotenv), cmd.Env)
+	bs = append(bs, subCommand...)
+
+	typeDict := map[string]flagType{}
+	if len(cmd.Flags) > 0 {
+		bs = append(bs, b.buildFlagStmt(ident, cmd, typeDict)...)
+		bs = append(bs, b.buildFallbackStmt(ident, cmd)...)
+		bs = append(bs, fishast.SetStatement("-l", "non_matched_args", fishast.RefRaw("argv")))
+		bs = append(bs, b.buildCheckStmt(ident, cmd)...)
+		bs = append(bs, b.buildPositionalStmt(ident, cmd, typeDict)...)
+		return b.buildMatchStmt(ident, cmd, bs, typeDict)
+	}
+	bs = append(bs, fishast.SetStatement("-l", "non_matched_args", fishast.RefRaw("argv")))
+	if len(cmd.Args) > 0 {
+		bs = append(bs, b.buildCheckStmt(ident, cmd)...)
+		bs = append(bs, b.buildPositionalStmt(ident, cmd, typeDict)...)
+	}
+	return b.buildMatchStmt(ident, cmd, bs, typeDict)
+}
+
+// buildEnvStmt loads the dotenv files and exports the variables of env.
+func (b *fishScriptBuilder) buildEnvStmt(dotenv []string, env map[string]string) []fishast.Stmt {
+	stmts := []fishast.Stmt{}
+	if len(dotenv) > 0 {
+		// source succeeds on an empty input, the status of aliax is checked instead
+		stmts = append(stmts, fishast.RawStmt(dotenvCommand("fish", dotenv, fishast.Quote)+" | source; test $pipestatus[1] -eq 0; or return 1"))
+	}
+	for _, k := range cfg.SortedKeys(env) {
+		stmts = append(stmts, fishast.SetStatement("-lx", k, fishast.String(env[k])))
+	}
+	return stmts
+}
+
+// fishFlagSpec returns the argparse spec of the flag, the variable holding its
+// value, and the aliases argparse can't parse with the option they map to.
+func fishFlagSpec(flag cfg.Flag) (spec, variable, option string, extra []string) {
+	short, long := "", ""
+	for _, a := range flag.Alias {
+		switch {
+		case len(a) == 2 && a[0] == '-' && a[1] != '-' && len(short) == 0:
+			short = a[1:]
+		case len(a) > 3 && strings.HasPrefix(a, "--") && len(long) == 0:
+			long = a[2:]
+		default:
+			extra = append(extra, a)
+		}
+	}
+	if len(short) == 0 && len(long) == 0 {
+		long = flag.Name
+	}
+	switch {
+	case len(short) > 0 && len(long) > 0:
+		spec, variable, option = short+"/"+long, long, "--"+long
+	case len(long) > 0:
+		spec, variable, option = long, long, "--"+long
+	default:
+		spec, variable, option = short, short, "-"+short
+	}
+	switch flagTypeOf(flag) {
+	case flagTypeString:
+		spec += "="
+	case flagTypeList:
+		spec += "=+"
+	}
+	return spec, "_flag_" + strings.ReplaceAll(variable, "-", "_"), option, extra
+}
+
+// buildFlagStmt parses the flags with argparse and stores them in the variables
+// of the flags, the unknown options are left in argv like the positionals.
+func (b *fishScriptBuilder) buildFlagStmt(ident string, cmd *cfg.Command, typeDict map[string]flagType) []fishast.Stmt {
+	specs := []string{"argparse", "--ignore-unknown"}
+	stmts := []fishast.Stmt{}
+	// the aliases argparse can't parse are rewritten to the options it parses
+	rewrite := fishast.SwitchStmt{Cond: fishast.RefRaw("arg")}
+	rewritten := false
+	for _, flag := range cmd.Flags {
+		flagIdent := fmt.Sprintf("%s_%s", ident, flag.Name)
+		typeDict[flagIdent] = flagTypeOf(flag)
+		spec, variable, option, extra := fishFlagSpec(flag)
+		specs = append(specs, fishast.Quote(spec))
+		switch typeDict[flagIdent] {
+		case flagTypeBool:
+			ifStmt := fishast.IfStatement()
+			ifStmt.Cond = fishast.Raw("set -q " + variable)
+			ifStmt.Body.Append(fishast.SetStatement("", flagIdent, fishast.TRUE))
+			stmts = append(stmts, fishast.SetStatement("-l", flagIdent, fishast.FALSE), ifStmt)
+		default:
+			stmts = append(stmts, fishast.SetStatement("-l", flagIdent, fishast.RefRaw(variable)))
+		}
+		if len(extra) > 0 {
+			rewritten = true
+			c := fishast.CaseStatement(fishPatterns(extra)...)
+			c.Body.Append(fishast.SetStatement("", "arg", fishast.String(option)))
+			if typeDict[flagIdent] != flagTypeBool {
+				c.Body.Append(fishast.SetStatement("", "aliax_next", fishast.Identifier("value")))
+			}
+			rewrite.Cases = append(rewrite.Cases, c)
+		}
+	}
+	if !rewritten {
+		specs = append(specs, "-- $argv; or return")
+		return append([]fishast.Stmt{fishast.RawStmt(strings.Join(specs, " "))}, stmts...)
+	}
+	specs = append(specs, "-- $aliax_argv; or return")
+	return append(b.buildRewriteStmt(cmd, &rewrite), append([]fishast.Stmt{fishast.RawStmt(strings.Join(specs, " "))}, stmts...)...)
+}
+
+// buildRewriteStmt copies argv to aliax_argv, rewriting the aliases matched by rewrite.
+func (b *fishScriptBuilder) buildRewriteStmt(cmd *cfg.Command, rewrite *fishast.SwitchStmt) []fishast.Stmt {
+	values := []string{}
+	for _, flag := range cmd.Flags {
+		if flagTypeOf(flag) == flagTypeBool {
+			continue
+		}
+		_, _, _, extra := fishFlagSpec(flag)
+		for _, a := range flag.Alias {
+			if !slices.Contains(extra, a) {
+				values = append(values, a)
+			}
+		}
+		if len(flag.Alias) == 0 {
+			values = append(values, "--"+flag.Name)
+		}
+	}
+	rest := fishast.CaseStatement(fishast.String("--"))
+	rest.Body.Append(fishast.SetStatement("", "aliax_next", fishast.Identifier("rest")))
+	rewrite.Cases = append([]*fishast.CaseStmt{rest}, rewrite.Cases...)
+	if len(values) > 0 {
+		value := fishast.CaseStatement(fishPatterns(values)...)
+		value.Body.Append(fishast.SetStatement("", "aliax_next", fishast.Identifier("value")))
+		rewrite.Cases = append(rewrite.Cases, value)
+	}
+
+	ifStmt := fishast.IfStatement()
+	ifStmt.Cond = fishast.TestExpression(fishast.RefRaw("aliax_next"), fishtoken.EQ, fishast.Identifier("flag"))
+	ifStmt.Body.Append(rewrite)
+	elseStmt := fishast.IfStatement()
+	elseStmt.Cond = fishast.TestExpression(fishast.RefRaw("aliax_next"), fishtoken.EQ, fishast.Identifier("value"))
+	elseStmt.Body.Append(fishast.SetStatement("", "aliax_next", fishast.Identifier("flag")))
+	ifStmt.Else = elseStmt
+
+	forStmt := fishast.ForStatement("arg", fishast.RefRaw("argv"))
+	forStmt.Body.Append(ifStmt, fishast.SetStatement("-a", "aliax_argv", fishast.RefRaw("arg")))
+	return []fishast.Stmt{
+		fishast.SetStatement("-l", "aliax_argv"),
+		fishast.SetStatement("-l", "aliax_next", fishast.Identifier("flag")),
+		forStmt,
+	}
+}
+
+// fishPatterns returns the patterns of a case matching the words.
+func fishPatterns(words []string) []fishast.Expr {
+	patterns := []fishast.Expr{}
+	for _, w := range words {
+		patterns = append(patterns, fishast.String(w))
+	}
+	return patterns
+}
+
+// buildFallbackStmt sets the flags which weren't passed on the command line
+// from their environment variable, and then from their default value.
+func (b *fishScriptBuilder) buildFallbackStmt(ident string, cmd *cfg.Command) []fishast.Stmt {
+	stmts := []fishast.Stmt{}
+	fallback := func(cond string, set fishast.Stmt) {
+		ifStmt := fishast.IfStatement()
+		ifStmt.Cond = fishast.Raw(cond)
+		ifStmt.Body.Append(set)
+		stmts = append(stmts, ifStmt)
+	}
+	for _, flag := range cmd.Flags {
+		flagIdent := fmt.Sprintf("%s_%s", ident, flag.Name)
+		defaults := flag.Defaults()
+		switch flagTypeOf(flag) {
+		case flagTypeString:
+			if len(flag.Env) > 0 {
+				fallback(fmt.Sprintf(`test -z "$%s"`, flagIdent), fishast.SetStatement("", flagIdent, fishast.QuotedRef(flag.Env)))
+			}
+			if len(defaults) > 0 {
+				fallback(fmt.Sprintf(`test -z "$%s"`, flagIdent), fishast.SetStatement("", flagIdent, fishast.String(defaults[0])))
+			}
+		case flagTypeList:
+			if len(flag.Env) > 0 {
+				fallback(fmt.Sprintf(`test (count $%s) -eq 0; and test -n "$%s"`, flagIdent, flag.Env),
+					fishast.SetStatement("", flagIdent, fishast.Raw(fmt.Sprintf("(string split , -- $%s)", flag.Env))))
+			}
+			if len(defaults) > 0 {
+				values := []fishast.Expr{}
+				for _, v := range defaults {
+					values = append(values, fishast.String(v))
+				}
+				fallback(fmt.Sprintf(`test (count $%s) -eq 0`, flagIdent), fishast.SetStatement("", flagIdent, values...))
+			}
+		case flagTypeBool:
+			if len(flag.Env) > 0 {
+				fallback(fmt.Sprintf(`test "$%s" = false; and string match -qr '^(1|true)$' -- "$%s"`, flagIdent, flag.Env),
+					fishast.SetStatement("", flagIdent, fishast.TRUE))
+			}
+		}
+	}
+	return stmts
+}
+
+// buildCheckStmt validates the flags and positionals unless help is requested.
+func (b *fishScriptBuilder) buildCheckStmt(ident string, cmd *cfg.Command) []fishast.Stmt {
+	checks := []fishast.Stmt{}
+	for _, c := range checksOf(b, ident, cmd) {
+		ifStmt := fishast.IfStatement()
+		ifStmt.Cond = fishast.Raw(c.test)
+		ifStmt.Body.Append(b.buildErrorStmt(cmd, c.msg)...)
+		checks = append(checks, ifStmt)
+	}
+	if len(checks) == 0 || !hasHelpFlag(cmd) {
+		return checks
+	}
+	ifStmt := fishast.IfStatement()
+	ifStmt.Cond = fishast.TestExpression(fishast.QuotedRef(ident+"_help"), fishtoken.EQ, fishast.FALSE)
+	ifStmt.Body.Append(checks...)
+	return []fishast.Stmt{ifStmt}
+}
+
+// buildPositionalStmt assigns the positionals left over by the flags to the
+// variables of the declared arguments, the variadic one takes the rest as a list.
+func (b *fishScriptBuilder) buildPositionalStmt(ident string, cmd *cfg.Command, typeDict map[string]flagType) []fishast.Stmt {
+	stmts := []fishast.Stmt{}
+	for i, arg := range cmd.Args {
+		argIdent := fmt.Sprintf("%s_%s", ident, arg.Name)
+		// fish lists are indexed from 1, set -q tells whether the index exists
+		index := fmt.Sprintf("non_matched_args[%d]", i+1)
+		ifStmt := fishast.IfStatement()
+		ifStmt.Cond = fishast.Raw("set -q " + index)
+		if arg.Variadic {
+			typeDict[argIdent] = flagTypeList
+			ifStmt.Body.Append(fishast.SetStatement("", argIdent, fishast.Raw(fmt.Sprintf("$non_matched_args[%d..-1]", i+1))))
+		} else {
+			typeDict[argIdent] = flagTypeString
+			ifStmt.Body.Append(fishast.SetStatement("", argIdent, fishast.RefRaw(index)))
+		}
+		stmts = append(stmts, fishast.SetStatement("-l", argIdent), ifStmt)
+	}
+	return stmts
+}
+
+// buildHelpStmt prints the help of the command, redirect is appended to the command.
+func (b *fishScriptBuilder) buildHelpStmt(cmd *cfg.Command, redirect string) []fishast.Stmt {
+	help := cmd.HelpCmd(cmd.Name())
+	if len(help) == 0 {
+		return nil
+	}
+	args := []string{`'%s\n'`, fishast.Quote(help)}
+	if len(redirect) > 0 {
+		args = append(args, redirect)
+	}
+	return []fishast.Stmt{fishast.CallStatement("printf", args...)}
+}
+
+// buildErrorStmt prints the message and the help of the command to stderr and returns.
+func (b *fishScriptBuilder) buildErrorStmt(cmd *cfg.Command, msg string) []fishast.Stmt {
+	stmts := []fishast.Stmt{fishast.CallStatement("echo", `"`+msg+`"`, ">&2")}
+	stmts = append(stmts, b.buildHelpStmt(cmd, ">&2")...)
+	return append(stmts, fishast.CallStatement("return", "1"))
+}
+
+// buildRunStmt returns the lines of the command of a match case with the
+// references to flags, positionals and environment variables resolved.
+func (b *fishScriptBuilder) buildRunStmt(ident, run string, typeDict map[string]flagType) []fishast.Stmt {
+	run = indexResolver.apply(run, func(matched string) string {
+		return fmt.Sprintf("$non_matched_args[%s]", matched)
+	})
+	run = namedResolver.apply(run, func(matched string) string {
+		return fmt.Sprintf("$%s_%s", ident, matched)
+	})
+	run = envResolver.apply(run, func(matched string) string {
+		return fmt.Sprintf("$%s", matched)
+	})
+	stmts := []fishast.Stmt{}
+	lines := strings.Split(run, "\n")
+	for i, line := range lines {
+		if i == len(lines)-1 && len(line) == 0 {
+			continue
+		}
+		stmts = append(stmts, fishast.RawStmt(line))
+	}
+	return stmts
+}
+
+// buildCaseBody runs the statements in dir and returns their status.
+func (b *fishScriptBuilder) buildCaseBody(dir string, stmts ...fishast.Stmt) []fishast.Stmt {
+	if len(dir) == 0 {
+		dir = b.root
+	}
+	if len(dir) == 0 {
+		return append(stmts, fishast.CallStatement("return"))
+	}
+	body := []fishast.Stmt{fishast.CallStatement("pushd", fishast.Quote(dir))}
+	body = append(body, stmts...)
+	return append(body,
+		fishast.SetStatement("-l", "aliax_status", fishast.RefRaw("status")),
+		fishast.CallStatement("popd"),
+		fishast.CallStatement("return", "$aliax_status"))
+}
+
+// fishTest is the fish version of bashTest.
+func fishTest(name string, typ flagType, t cfg.Test) fishast.Expr {
+	var test fishast.Expr
+	switch {
+	case len(t.Op) == 0 && typ == flagTypeList:
+		test = fishast.TestExpression(fishast.Raw(fmt.Sprintf("(count $%s)", name)), fishtoken.GT, fishast.Number(0))
+	case len(t.Op) == 0 && typ == flagTypeBool:
+		test = fishast.TestExpression(fishast.QuotedRef(name), fishtoken.EQ, fishast.TRUE)
+	case len(t.Op) == 0:
+		test = fishast.Raw(fmt.Sprintf(`test -n "$%s"`, name))
+	case t.Op == "==" && typ == flagTypeBool:
+		test = fishast.TestExpression(fishast.QuotedRef(name), fishtoken.EQ, fishast.Raw(t.Values[0]))
+	case t.Op == "==":
+		test = fishast.TestExpression(fishast.QuotedRef(name), fishtoken.EQ, fishast.String(t.Values[0]))
+	case t.Op == "~":
+		test = fishast.Raw(fmt.Sprintf(`string match -qr -- %s "$%s"`, fishast.Quote(t.Values[0]), name))
+	case t.Op == "in":
+		values := []string{}
+		for _, v := range t.Values {
+			values = append(values, fishast.Quote(v))
+		}
+		test = fishast.Raw(fmt.Sprintf(`contains -- "$%s" %s`, name, strings.Join(values, " ")))
+	}
+	if t.Negate {
+		return fishast.NotExpression(test)
+	}
+	return test
+}
+
+func (b *fishScriptBuilder) buildMatchStmt(ident string, cmd *cfg.Command, bs []fishast.Stmt, typeDict map[string]flagType) []fishast.Stmt {
+	// the commands are resolved by buildRunStmt
+	match, defaults, defaultMatchCase := matchCases(b, cmd, func(run string) string { return run })
+
+	body := func(c *sortedMatchCase) []fishast.Stmt {
+		return b.buildCaseBody(c.dir, b.buildRunStmt(ident, c.body, typeDict)...)
+	}
+	var defaultStmt fishast.Stmt
+	if defaultMatchCase != nil {
+		defaultStmt = fishast.BlockStatement(body(defaultMatchCase)...)
+	}
+	for i := len(defaults) - 1; i >= 0; i-- {
+		ifStmt := fishast.IfStatement()
+		ifStmt.Cond = fishast.Raw(defaults[i].when)
+		ifStmt.Body.Append(body(&defaults[i])...)
+		if defaultStmt != nil {
+			ifStmt.Else = defaultStmt
+		}
+		defaultStmt = ifStmt
+	}
+	// the default case doesn't run when help is requested, so that the help gets printed
+	if defaultStmt != nil && hasHelpFlag(cmd) {
+		ifStmt := fishast.IfStatement()
+		ifStmt.Cond = fishast.TestExpression(fishast.QuotedRef(ident+"_help"), fishtoken.EQ, fishast.FALSE)
+		if block, ok := defaultStmt.(*fishast.BlockStmt); ok {
+			ifStmt.Body = block
+		} else {
+			ifStmt.Body.Append(defaultStmt)
+		}
+		defaultStmt = ifStmt
+	}
+
+	if len(match) == 0 {
+		switch stmt := defaultStmt.(type) {
+		case *fishast.IfStmt:
+			bs = append(bs, stmt)
+		case *fishast.BlockStmt:
+			bs = append(bs, stmt.List...)
+		}
+		return bs
+	}
+
+	matchStmt := fishast.IfStatement()
+	bs = append(bs, matchStmt)
+	for i := range match {
+		c := &match[i]
+		var cases fishast.Expr
+		for _, t := range c.tests {
+			name := fmt.Sprintf("%s_%s", ident, t.Name)
+			test := fishTest(name, typeDict[name], t)
+			if cases == nil {
+				cases = test
+			} else {
+				cases = fishast.BinaryExpression(cases, fishtoken.AND, test)
+			}
+		}
+		if len(c.when) > 0 {
+			cases = fishast.BinaryExpression(cases, fishtoken.AND, fishast.Raw(c.when))
+		}
+		matchStmt.Cond = cases
+		matchStmt.Body.Append(body(c)...)
+		if i != len(match)-1 {
+			ifStmt := fishast.IfStatement()
+			matchStmt.Else = ifStmt
+			matchStmt = ifStmt
+		}
+	}
+	if defaultStmt != nil {
+		matchStmt.Else = defaultStmt
+	}
+	return bs
+}
+
+func (b *fishScriptBuilder) platform() string { return "fish" }
+
+func (b *fishScriptBuilder) compile(e cond.Expr, resolve func(string) string) string {
+	return cond.Fish(e, resolve)
+}
+
+// fishEscaper escapes the characters special in a double-quoted fish string,
+// escaping $ also leaves the $(command) substitutions of fish 3.4 out.
+var fishEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+
+func (b *fishScriptBuilder) escape(text string) string { return fishEscaper.Replace(text) }
+
+func (b *fishScriptBuilder) isEmpty(name string, typ flagType) string {
+	if typ == flagTypeList {
+		return fmt.Sprintf("test (count $%s) -eq 0", name)
+	}
+	return fmt.Sprintf(`test -z "$%s"`, name)
+}
+
+func (b *fishScriptBuilder) mismatches(name, pattern string) string {
+	return fmt.Sprintf(`test -n "$%s"; and not string match -qr -- %s "$%s"`, name, fishast.Quote(pattern), name)
+}
+
+func (b *fishScriptBuilder) argCount() string { return "$(count $non_matched_args)" }
+
+func (b *fishScriptBuilder) compareArgs(op string, n int) string {
+	return fmt.Sprintf("test (count $non_matched_args) %s %d", op, n)
+}
@@ -0,0 +1,256 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	bashast "aliax/internal/ast/bash"
+	"aliax/internal/cfg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+// TestMatchTests checks that the generators compile the tests of match
+// patterns into equivalent conditions.
+func TestMatchTests(t *testing.T) {
+	types := map[string]flagType{"env": flagTypeString, "force": flagTypeBool, "tags": flagTypeList}
+	for _, tt := range []struct {
+		pattern, bash, ps, fish string
+	}{
+		{`env`, `-n "$hi_env"`, `$null -ne $hi_env`, `test -n "$hi_env"`},
+		{`[force, tags]`, `$hi_force == true`, `$hi_force -ne $false`, `test "$hi_force" = true`},
+		{`{env: null}`, `-n "$hi_env"`, `$null -ne $hi_env`, `test -n "$hi_env"`},
+		{`{env: prod}`, `"$hi_env" == 'prod'`, `$hi_env -ceq 'prod'`, `test "$hi_env" = 'prod'`},
+		{`{env: "!prod"}`, `! ( "$hi_env" == 'prod' )`, `-not ($hi_env -ceq 'prod')`, `not test "$hi_env" = 'prod'`},
+		{`{env: "=!it's"}`, `"$hi_env" == '!it'\''s'`, `$hi_env -ceq '!it''s'`, `test "$hi_env" = '!it\'s'`},
+		{`{env: 3}`, `"$hi_env" == '3'`, `$hi_env -ceq '3'`, `test "$hi_env" = '3'`},
+		{`{env: "~^(dev|prod) env$"}`, `"$hi_env" =~ ^(dev|prod)\ env$`, `$hi_env -cmatch '^(dev|prod) env$'`, `string match -qr -- '^(dev|prod) env$' "$hi_env"`},
+		{`{env: "!~^dev"}`, `! ( "$hi_env" =~ ^dev )`, `-not ($hi_env -cmatch '^dev')`, `not string match -qr -- '^dev' "$hi_env"`},
+		{`{env: [dev, prod]}`, `( "$hi_env" == 'dev' || "$hi_env" == 'prod' )`, `@('dev', 'prod') -ccontains $hi_env`, `contains -- "$hi_env" 'dev' 'prod'`},
+		{`{env: {not: [dev, prod]}}`, `! ( ( "$hi_env" == 'dev' || "$hi_env" == 'prod' ) )`, `-not (@('dev', 'prod') -ccontains $hi_env)`, `not contains -- "$hi_env" 'dev' 'prod'`},
+		{`{env: {not: null}}`, `! ( -n "$hi_env" )`, `-not ($null -ne $hi_env)`, `not test -n "$hi_env"`},
+		{`{force: true}`, `$hi_force == true`, `$hi_force -eq $true`, `test "$hi_force" = true`},
+		{`{force: false}`, `$hi_force == false`, `$hi_force -eq $false`, `test "$hi_force" = false`},
+		{`{tags: null}`, `${#hi_tags[@]} -gt 0`, `$hi_tags.Count -gt 0`, `test (count $hi_tags) -gt 0`},
+	} {
+		var c cfg.Case
+		assert.NoError(t, yaml.Unmarshal([]byte("pattern: "+tt.pattern), &c))
+		tests, err := c.Tests()
+		if !assert.NoError(t, err, tt.pattern) || !assert.NotEmpty(t, tests, tt.pattern) {
+			continue
+		}
+		name := "hi_" + tests[0].Name
+		assert.Equal(t, tt.bash, bashTest(name, types[tests[0].Name], tests[0]).String(), tt.pattern)
+		assert.Equal(t, tt.ps, psTest(name, types[tests[0].Name], tests[0]).String(), tt.pattern)
+		assert.Equal(t, tt.fish, fishTest(name, types[tests[0].Name], tests[0]).String(), tt.pattern)
+	}
+}
+
+func TestSortMatchCases(t *testing.T) {
+	match := []sortedMatchCase{
+		{rank: [2]int{0, 1}, body: "a"},
+		{rank: [2]int{0, 2}, body: "b"},
+		{rank: [2]int{0, 1}, body: "c"},
+		{rank: [2]int{1, 0}, body: "d"},
+		{rank: [2]int{0, 1}, body: "e"},
+		{rank: [2]int{0, 2}, body: "f"},
+	}
+	sortMatchCases(match)
+	bodies := []string{}
+	for _, c := range match {
+		bodies = append(bodies, c.body)
+	}
+	assert.Equal(t, []string{"d", "b", "f", "a", "c", "e"}, bodies)
+}
+
+func TestFishFlagSpec(t *testing.T) {
+	for _, tt := range []struct {
+		flag                   string
+		spec, variable, option string
+		extra                  []string
+	}{
+		{`{name: force, type: bool, alias: [-f, --force]}`, "f/force", "_flag_force", "--force", nil},
+		{`{name: dry-run, type: bool}`, "dry-run", "_flag_dry_run", "--dry-run", nil},
+		{`{name: env, type: string, alias: [-e]}`, "e=", "_flag_e", "-e", nil},
+		{`{name: tags, type: list, alias: [--tags, -t, -T]}`, "t/tags=+", "_flag_tags", "--tags", []string{"-T"}},
+		{`{name: init, type: bool, alias: [., init]}`, "init", "_flag_init", "--init", []string{".", "init"}},
+	} {
+		var flag cfg.Flag
+		if assert.NoError(t, yaml.Unmarshal([]byte(tt.flag), &flag), tt.flag) {
+			spec, variable, option, extra := fishFlagSpec(flag)
+			assert.Equal(t, []any{tt.spec, tt.variable, tt.option, tt.extra}, []any{spec, variable, option, extra}, tt.flag)
+		}
+	}
+}
+
+// TestChecksOf checks that only the references of the messages expand.
+func TestChecksOf(t *testing.T) {
+	var cmd cfg.Command
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+flags:
+  - name: mode
+    type: enum
+    choices: ["$HOME", '"x"']
+args:
+  - name: file
+`), &cmd))
+	for _, tt := range []struct {
+		d    dialect
+		msgs []string
+	}{
+		{&bashScriptBuilder{shell: "bash"}, []string{
+			`invalid value '$hi_mode' for flag mode: expected one of \$HOME, \"x\"`,
+			`accepts at most 1 arg(s), received ${#non_matched_args[@]}`}},
+		{&psScriptBuilder{}, []string{
+			"invalid value '$hi_mode' for flag mode: expected one of `$HOME, `\"x`\"",
+			`accepts at most 1 arg(s), received $($non_matched_args.Count)`}},
+		{&fishScriptBuilder{}, []string{
+			`invalid value '$hi_mode' for flag mode: expected one of \$HOME, \"x\"`,
+			`accepts at most 1 arg(s), received $(count $non_matched_args)`}},
+	} {
+		msgs := []string{}
+		for _, c := range checksOf(tt.d, "hi", &cmd) {
+			msgs = append(msgs, c.msg)
+		}
+		assert.Equal(t, tt.msgs, msgs, tt.d.platform())
+	}
+}
+
+func TestZshFlagSpecs(t *testing.T) {
+	for _, tt := range []struct {
+		flag  string
+		specs []string
+	}{
+		{`{name: force, type: bool, alias: [-f, --force], usage: "force it"}`, []string{`'(-f --force)-f[force it]'`, `'(-f --force)--force[force it]'`}},
+		{`{name: env, type: string, alias: [--env], usage: "env [dev]: it's"}`, []string{`'(--env)--env[env \[dev\]\: it'\''s]:env:_default'`}},
+		{`{name: mode, type: enum, alias: [-m], choices: [a, b c]}`, []string{`'(-m)-m:mode:(a b\ c)'`}},
+		{`{name: tags, type: list, alias: [-t, --tags]}`, []string{`'*-t:tags:_default'`, `'*--tags:tags:_default'`}},
+		{`{name: level, type: int, alias: [-l]}`, []string{`'(-l)-l:int:'`}},
+		{`{name: dry, type: bool}`, []string{}},
+	} {
+		var flag cfg.Flag
+		if assert.NoError(t, yaml.Unmarshal([]byte(tt.flag), &flag), tt.flag) {
+			assert.Equal(t, tt.specs, zshFlagSpecs(flag), tt.flag)
+		}
+	}
+}
+
+func TestZshCompletion(t *testing.T) {
+	var cmd cfg.Command
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+flags:
+  - name: env
+    type: string
+    alias: [--env]
+command:
+  up:
+    short: "start: all"
+    args:
+      - name: service
+      - name: rest
+        usage: more services
+        variadic: true
+`), &cmd))
+	var buf strings.Builder
+	bashast.Print(&bashast.File{Stmts: buildZshCompletion("_hi", &cmd)}, &buf)
+	assert.Equal(t, `_hi() {
+  local context state state_descr line
+  typeset -A opt_args
+  local -a commands=('up:start: all')
+  _arguments -C \
+    '(--env)--env:env:_default' \
+    '1: :->command' \
+    '*:: :->args'
+  case "$state" in
+    command)
+      _describe -t commands command commands
+      ;;
+    args)
+      case "${words[1]}" in
+        'up')
+          _hi_up
+          ;;
+      esac
+      ;;
+  esac
+}
+_hi_up() {
+  _arguments -s \
+    '1:service:_default' \
+    '*:more services:_default'
+}
+`, buf.String())
+}
+
+// TestZshPlatform checks that the zsh functions run the bash cases
+// unless some cases are written for zsh.
+func TestZshPlatform(t *testing.T) {
+	for _, tt := range []struct {
+		match      string
+		want, skip []string
+	}{
+		{`[{run: echo bash, platform: bash}, {run: echo fish, platform: fish}]`, []string{"echo bash"}, []string{"echo fish"}},
+		{`[{run: echo bash, platform: bash}, {run: echo zsh, platform: zsh}]`, []string{"echo zsh"}, []string{"echo bash"}},
+		{`[{pattern: force, run: echo bash, platform: bash}, {run: echo all}]`, []string{"echo bash", "echo all"}, nil},
+	} {
+		var cmd cfg.Command
+		assert.NoError(t, yaml.Unmarshal([]byte("flags: [{name: force, type: bool}]\nmatch: "+tt.match), &cmd))
+		dir := t.TempDir()
+		assert.NoError(t, os.Mkdir(filepath.Join(dir, "zsh"), 0755))
+		_, err := (&runScriptsBuilder{}).generateZshCommand(dir, "hi", &cmd)
+		if !assert.NoError(t, err, tt.match) {
+			continue
+		}
+		script, err := os.ReadFile(filepath.Join(dir, "zsh", "hi"))
+		assert.NoError(t, err)
+		for _, run := range tt.want {
+			assert.Contains(t, string(script), run, tt.match)
+		}
+		for _, run := range tt.skip {
+			assert.NotContains(t, string(script), run, tt.match)
+		}
+	}
+}
+
+func TestCaseWhen(t *testing.T) {
+	for _, d := range []dialect{&bashScriptBuilder{shell: "bash"}, &psScriptBuilder{}, &fishScriptBuilder{}} {
+		for _, platform := range []string{"batch", "windows", "posix"} {
+			_, ok := caseWhen(d, cfg.Case{Platform: platform, Run: "set X=%CD%"})
+			assert.False(t, ok, d.platform()+" "+platform)
+		}
+		test, ok := caseWhen(d, cfg.Case{Platform: d.platform()})
+		assert.True(t, ok, d.platform())
+		assert.Empty(t, test, d.platform())
+	}
+}
+
+// TestPowershellHelp checks that the help printed by the PowerShell
+// scripts is escaped, the default values are quoted in it.
+func TestPowershellHelp(t *testing.T) {
+	var cmd cfg.Command
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+flags:
+  - name: env
+    type: string
+    default: dev
+  - name: tag
+    type: string
+    required: true
+match:
+  - run: echo $env
+`), &cmd))
+	dir := t.TempDir()
+	_, err := (&runScriptsBuilder{}).generatePowershellCommand(dir, "hi", &cmd)
+	if !assert.NoError(t, err) {
+		return
+	}
+	script, err := os.ReadFile(filepath.Join(dir, "hi.ps1"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(script), "(default `\"dev`\")")
+	assert.NotContains(t, string(script), `(default "dev")`)
+}
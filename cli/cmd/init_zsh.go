@@ -0,0 +1,227 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/aos"
+	bashast "aliax/internal/ast/bash"
+	"aliax/internal/cfg"
+	"aliax/internal/log"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// zshScriptBuilder generates an autoloadable zsh function for a command or
+// an extension, written to zsh/name where zsh finds it once the directory
+// is added to $fpath. The function runs the statements of the bash script
+// in a subshell, with the options making zsh index and split like bash,
+// so that exit and cd don't affect the shell of the caller.
+type zshScriptBuilder struct {
+	*bashScriptBuilder
+}
+
+// createZshFile creates a file of the zsh directory, existing files are
+// only overwritten by init --force.
+func createZshFile(dir, name string) (*os.File, error) {
+	filename := filepath.Join(dir, "zsh", name)
+
+	fp, err := aos.Create(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) && !initParameter.force {
+			log.WithError(err).
+				WithField("file", filename).
+				WithField("suggestion", suggestionCleanWorkspace).
+				Fatal("file already exist")
+		} else {
+			return nil, err
+		}
+	}
+	return fp, nil
+}
+
+func newZshScriptBuilder(dir, name string, cmd *cfg.Command) (*zshScriptBuilder, error) {
+	fp, err := createZshFile(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	builder := &zshScriptBuilder{&bashScriptBuilder{
+		file:  fp,
+		node:  &bashast.File{},
+		cmd:   cmd,
+		ident: name,
+		shell: "zsh",
+	}}
+	builder.node.Append(bashast.Docs(copyright))
+	return builder, nil
+}
+
+// subshell returns the subshell the statements of the function run in.
+func (b *zshScriptBuilder) subshell() *bashast.SubshellStmt {
+	return bashast.SubshellStatement(
+		bashast.RawStmt("setopt ksh_arrays sh_word_split"),
+		bashast.RawStmt("set -e"))
+}
+
+func (s *runScriptsBuilder) generateZshExtension(dir, name string, cmd *cfg.Command) (*zshScriptBuilder, error) {
+	zshBuilder, err := newZshScriptBuilder(dir, name, cmd)
+	if err != nil {
+		log.WithError(err).Error("creating zsh builder")
+		return nil, err
+	}
+	defer zshBuilder.close()
+	body := zshBuilder.subshell()
+	body.Body.Append(zshBuilder.buildDirStmt(s.root)...)
+	body.Body.Append(zshBuilder.buildEnvStmt(s.dotenv, s.env)...)
+	body.Body.Append(bashast.AssignStatement(
+		bashast.Identifier(executable),
+		bashast.String(cmd.Bin),
+	), bashast.RawStmt(`args=("$@")`))
+	body.Body.Append(zshBuilder.generateExtension(name, name, 0, cmd)...)
+	// the function shadows the executable when they have the same name
+	body.Body.Append(bashast.RawStmt(fmt.Sprintf(`command $%s "${args[@]}"`, executable)))
+	zshBuilder.node.Append(body)
+
+	bashast.Print(zshBuilder.node, zshBuilder.file)
+	return zshBuilder, nil
+}
+
+// generateZshCommand generates the function of the command and its completion
+// function. Extensions have none, so that they keep the completion of the executable.
+func (s *runScriptsBuilder) generateZshCommand(dir, name string, cmd *cfg.Command) (*zshScriptBuilder, error) {
+	zshBuilder, err := newZshScriptBuilder(dir, name, cmd)
+	if err != nil {
+		log.WithError(err).Error("creating zsh builder")
+		return nil, err
+	}
+	defer zshBuilder.close()
+	body := zshBuilder.subshell()
+	body.Body.Append(zshBuilder.buildDirStmt(s.root)...)
+	body.Body.Append(zshBuilder.buildEnvStmt(s.dotenv, s.env)...)
+	body.Body.Append(bashast.RawStmt(`args=("$@")`))
+	body.Body.Append(zshBuilder.generateCommand(name, name, 0, cmd)...)
+	zshBuilder.node.Append(body)
+	bashast.Print(zshBuilder.node, zshBuilder.file)
+
+	fp, err := createZshFile(dir, "_"+name)
+	if err != nil {
+		log.WithError(err).Error("creating zsh completion")
+		return nil, err
+	}
+	defer fp.Close()
+	completion := &bashast.File{}
+	completion.Append(bashast.Docs("compdef "+name), bashast.Docs(copyright))
+	completion.Append(buildZshCompletion("_"+name, cmd)...)
+	// the file is the body of the completion function when it's autoloaded,
+	// which is redefined by the file and then called
+	ifStmt := bashast.IfStatement()
+	ifStmt.Cond = bashast.Raw(fmt.Sprintf(`"${funcstack[1]}" == %s`, bashQuote("_"+name)))
+	ifStmt.Body.Append(bashast.CallStatement("_"+name, `"$@"`))
+	completion.Append(ifStmt)
+	bashast.Print(completion, fp)
+	return zshBuilder, nil
+}
+
+// zshEscape escapes the characters _arguments and _describe interpret
+// in the descriptions and values of their specs.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `:`, `\:`).Replace(s)
+}
+
+// zshFlagSpecs returns the _arguments specs of the flag, one per alias.
+func zshFlagSpecs(flag cfg.Flag) []string {
+	specs := []string{}
+	prefix := fmt.Sprintf("(%s)", strings.Join(flag.Alias, " "))
+	arg := ""
+	switch flag.Type {
+	case "bool":
+	case "enum":
+		choices := []string{}
+		for _, c := range flag.Choices {
+			choices = append(choices, strings.ReplaceAll(zshEscape(c), " ", `\ `))
+		}
+		arg = fmt.Sprintf(":%s:(%s)", zshEscape(flag.Name), strings.Join(choices, " "))
+	case "string":
+		arg = fmt.Sprintf(":%s:_default", zshEscape(flag.Name))
+	case "list":
+		prefix = "*"
+		arg = fmt.Sprintf(":%s:_default", zshEscape(flag.Name))
+	default:
+		// the values of typed flags can't be completed, the type is shown instead
+		arg = fmt.Sprintf(":%s:", zshEscape(flag.Expected()))
+	}
+	desc := ""
+	if len(flag.Usage) > 0 {
+		desc = "[" + zshEscape(flag.Usage) + "]"
+	}
+	for _, alias := range flag.Alias {
+		if !strings.HasPrefix(alias, "-") {
+			continue
+		}
+		specs = append(specs, bashQuote(prefix+alias+desc+arg))
+	}
+	return specs
+}
+
+// buildZshCompletion returns the completion functions of the command and of
+// its subcommands, fn completes the command and fn_sub its subcommand sub.
+func buildZshCompletion(fn string, cmd *cfg.Command) []bashast.Stmt {
+	specs := []string{}
+	for _, flag := range cmd.Flags {
+		specs = append(specs, zshFlagSpecs(flag)...)
+	}
+	funcStmt := bashast.FuncStatement(fn)
+	stmts := []bashast.Stmt{funcStmt}
+	if len(cmd.Command) == 0 {
+		for i, arg := range cmd.Args {
+			desc := arg.Usage
+			if len(desc) == 0 {
+				desc = arg.Name
+			}
+			if arg.Variadic {
+				specs = append(specs, bashQuote(fmt.Sprintf("*:%s:_default", zshEscape(desc))))
+			} else {
+				specs = append(specs, bashQuote(fmt.Sprintf("%d:%s:_default", i+1, zshEscape(desc))))
+			}
+		}
+		funcStmt.Body.Append(zshArguments("-s", specs))
+		return stmts
+	}
+
+	commands := []string{}
+	switchStmt := &bashast.SwitchStmt{Cond: bashast.String("${words[1]}")}
+	for _, name := range cfg.SortedKeys(cmd.Command) {
+		command := zshEscape(name)
+		if short := cmd.Command[name].Short; len(short) > 0 {
+			command += ":" + short
+		}
+		commands = append(commands, bashQuote(command))
+		caseStmt := bashast.CaseStatement(bashast.Raw(bashQuote(name)))
+		caseStmt.Body.Append(bashast.RawStmt(fn + "_" + name))
+		switchStmt.Cases = append(switchStmt.Cases, caseStmt)
+		stmts = append(stmts, buildZshCompletion(fn+"_"+name, cmd.Command[name])...)
+	}
+	// the words after the subcommand are completed by its function
+	specs = append(specs, bashQuote("1: :->command"), bashQuote("*:: :->args"))
+	stateStmt := &bashast.SwitchStmt{Cond: bashast.String("$state")}
+	commandCase := bashast.CaseStatement(bashast.Raw("command"))
+	commandCase.Body.Append(bashast.CallStatement("_describe", "-t", "commands", "command", "commands"))
+	argsCase := bashast.CaseStatement(bashast.Raw("args"))
+	argsCase.Body.Append(switchStmt)
+	stateStmt.Cases = append(stateStmt.Cases, commandCase, argsCase)
+	funcStmt.Body.Append(
+		bashast.RawStmt("local context state state_descr line"),
+		bashast.RawStmt("typeset -A opt_args"),
+		bashast.RawStmt(fmt.Sprintf("local -a commands=(%s)", strings.Join(commands, " "))),
+		zshArguments("-C", specs),
+		stateStmt)
+	return stmts
+}
+
+// zshArguments calls _arguments with a spec per line.
+func zshArguments(option string, specs []string) bashast.Stmt {
+	return bashast.RawStmt(strings.Join(append([]string{"_arguments " + option}, specs...), " \\\n    "))
+}
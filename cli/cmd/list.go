@@ -0,0 +1,297 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/cfg"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/caarlos0/log"
+	"github.com/spf13/cobra"
+)
+
+// listCmdParameter stores parameters for the "list" command.
+type listCmdParameter struct {
+	tree bool
+	json bool
+}
+
+var (
+	listParameter listCmdParameter
+	listCmd       = &cobra.Command{
+		Use:   "list",
+		Short: "List the scripts, commands and extensions of the workspace",
+		Long: `The "list" command prints every script, custom command with its subcommands and extended binary
+declared by the aliax configuration and the files it includes, along with their short description,
+flags and the platforms they support. Entries are grouped by the file declaring them and by kind.`,
+		Example: "  aliax list\n  aliax list --tree\n  aliax list --json",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			file, err := cfg.Load(config)
+			if err != nil {
+				FatalConfig(err, "fail to parse file")
+			}
+			groups := listGroups(file, config)
+			w := cmd.OutOrStdout()
+			switch {
+			case listParameter.json:
+				err = writeListJSON(w, groups)
+			case listParameter.tree:
+				err = writeListTree(w, groups)
+			default:
+				err = writeListTable(w, groups)
+			}
+			if err != nil {
+				log.WithError(err).Fatal("writing list")
+			}
+		},
+	}
+)
+
+func init() {
+	aliaxCmd.AddCommand(listCmd)
+	listCmd.Flags().BoolVar(&listParameter.tree, "tree", false, "Print the entries as a tree showing the subcommands and flags")
+	listCmd.Flags().BoolVar(&listParameter.json, "json", false, "Print the entries as JSON")
+	listCmd.MarkFlagsMutuallyExclusive("tree", "json")
+}
+
+// listGroup holds the entries of a kind declared by a file.
+type listGroup struct {
+	File    string       `json:"file"`
+	Kind    string       `json:"kind"`
+	Entries []*listEntry `json:"entries"`
+}
+
+// listEntry describes a script, command or extension. Platforms is empty
+// when the entry runs on every platform.
+type listEntry struct {
+	Name      string       `json:"name"`
+	Short     string       `json:"short,omitempty"`
+	Bin       string       `json:"bin,omitempty"`
+	Flags     []listFlag   `json:"flags,omitempty"`
+	Platforms []string     `json:"platforms,omitempty"`
+	Commands  []*listEntry `json:"commands,omitempty"`
+}
+
+type listFlag struct {
+	Name  string   `json:"name"`
+	Alias []string `json:"alias,omitempty"`
+	Type  string   `json:"type"`
+	Usage string   `json:"usage,omitempty"`
+}
+
+// String returns the flag as shown by the tree, e.g. -o, --output <string>.
+func (f listFlag) String() string {
+	s := f.Name
+	if len(f.Alias) > 0 {
+		s = strings.Join(f.Alias, ", ")
+	}
+	if f.Type != "bool" {
+		s += " <" + f.Type + ">"
+	}
+	return s
+}
+
+// listKinds are the kinds of entries in the order they're listed,
+// along with the section of the configuration declaring them.
+var listKinds = []struct{ kind, section string }{
+	{"command", "command"},
+	{"extension", "extend"},
+	{"script", "script"},
+}
+
+// listGroups groups the entries of file by source file, in load order,
+// and by kind. Entries without a known source are attributed to name.
+func listGroups(file *cfg.Aliax, name string) []*listGroup {
+	files := file.Files()
+	if len(files) == 0 {
+		files = []string{name}
+	}
+	groups := []*listGroup{}
+	for _, f := range files {
+		for _, k := range listKinds {
+			g := &listGroup{File: displayPath(f), Kind: k.kind}
+			for _, entry := range listSection(file, k.section) {
+				source := file.Source(k.section, entry.Name)
+				if len(source) == 0 {
+					source = name
+				}
+				if source == f {
+					g.Entries = append(g.Entries, entry)
+				}
+			}
+			if len(g.Entries) > 0 {
+				groups = append(groups, g)
+			}
+		}
+	}
+	return groups
+}
+
+// listSection returns the entries of a section sorted by name.
+func listSection(file *cfg.Aliax, section string) []*listEntry {
+	entries := []*listEntry{}
+	switch section {
+	case "command":
+		for _, name := range cfg.SortedKeys(file.Command) {
+			entries = append(entries, listCommand(name, file.Command[name]))
+		}
+	case "extend":
+		for _, name := range cfg.SortedKeys(file.Extend) {
+			entries = append(entries, listCommand(name, file.Extend[name]))
+		}
+	case "script":
+		for _, name := range cfg.SortedKeys(file.Script) {
+			entry := &listEntry{Name: name}
+			if sc := file.Script[name]; sc.Cmd != nil {
+				entry = listCommand(name, sc.Cmd)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func listCommand(name string, cmd *cfg.Command) *listEntry {
+	entry := &listEntry{Name: name}
+	if cmd == nil {
+		return entry
+	}
+	entry.Short = cmd.Short
+	entry.Bin = cmd.Bin
+	for _, f := range cmd.Flags {
+		entry.Flags = append(entry.Flags, listFlag{Name: f.Name, Alias: f.Alias, Type: f.Type, Usage: f.Usage})
+	}
+	platforms := map[string]struct{}{}
+	for _, c := range cmd.Match {
+		if len(c.Platform) == 0 {
+			// a case without platform runs everywhere
+			platforms = nil
+			break
+		}
+		platforms[c.Platform] = struct{}{}
+	}
+	entry.Platforms = cfg.SortedKeys(platforms)
+	if len(entry.Platforms) == 0 {
+		entry.Platforms = nil
+	}
+	for _, sub := range cfg.SortedKeys(cmd.Command) {
+		entry.Commands = append(entry.Commands, listCommand(sub, cmd.Command[sub]))
+	}
+	return entry
+}
+
+// displayPath returns name relative to the workspace root when it's below it.
+func displayPath(name string) string {
+	if !filepath.IsAbs(name) {
+		return name
+	}
+	if rel, err := filepath.Rel(cfg.Root(), name); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return name
+}
+
+func writeListJSON(w io.Writer, groups []*listGroup) error {
+	data, err := json.MarshalIndent(groups, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// writeListTable prints a table per file, subcommands are listed
+// after their parent with their full path.
+func writeListTable(w io.Writer, groups []*listGroup) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	file := ""
+	for _, g := range groups {
+		if g.File != file {
+			if len(file) > 0 {
+				fmt.Fprintln(tw)
+			}
+			file = g.File
+			fmt.Fprintln(tw, file)
+			fmt.Fprintln(tw, "  KIND\tNAME\tSHORT\tFLAGS\tPLATFORMS")
+		}
+		var walk func(prefix string, entries []*listEntry)
+		walk = func(prefix string, entries []*listEntry) {
+			for _, e := range entries {
+				flags := []string{}
+				for _, f := range e.Flags {
+					flags = append(flags, f.Name)
+				}
+				fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", g.Kind, prefix+e.Name, e.Short, strings.Join(flags, ", "), platformsOf(e))
+				walk(prefix+e.Name+" ", e.Commands)
+			}
+		}
+		walk("", g.Entries)
+	}
+	return tw.Flush()
+}
+
+// writeListTree prints the files, kinds, entries, flags and subcommands as a tree.
+func writeListTree(w io.Writer, groups []*listGroup) error {
+	sb := &strings.Builder{}
+	var walk func(indent string, entries []*listEntry)
+	walk = func(indent string, entries []*listEntry) {
+		for i, e := range entries {
+			branch, next := "├── ", "│   "
+			if i == len(entries)-1 {
+				branch, next = "└── ", "    "
+			}
+			line := e.Name
+			if len(e.Short) > 0 {
+				line += ": " + e.Short
+			}
+			sb.WriteString(fmt.Sprintf("%s%s%s [%s]\n", indent, branch, line, platformsOf(e)))
+			children := []*listEntry{}
+			for _, f := range e.Flags {
+				children = append(children, &listEntry{Name: f.String(), Short: f.Usage})
+			}
+			for j, c := range children {
+				b := "├── "
+				if j == len(children)-1 && len(e.Commands) == 0 {
+					b = "└── "
+				}
+				line := c.Name
+				if len(c.Short) > 0 {
+					line += ": " + c.Short
+				}
+				sb.WriteString(indent + next + b + line + "\n")
+			}
+			walk(indent+next, e.Commands)
+		}
+	}
+	file := ""
+	for i, g := range groups {
+		if g.File != file {
+			file = g.File
+			sb.WriteString(file + "\n")
+		}
+		last := i == len(groups)-1 || groups[i+1].File != file
+		branch, next := "├── ", "│   "
+		if last {
+			branch, next = "└── ", "    "
+		}
+		sb.WriteString(branch + g.Kind + "s\n")
+		walk(next, g.Entries)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// platformsOf returns the platforms of the entry as shown by the table and the tree.
+func platformsOf(e *listEntry) string {
+	if len(e.Platforms) == 0 {
+		return "all"
+	}
+	return strings.Join(e.Platforms, ", ")
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/cfg"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestList(t *testing.T) {
+	var file cfg.Aliax
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+command:
+  hi:
+    short: say hello
+    flags:
+      - name: verbose
+        alias: ["-v", "--verbose"]
+        type: bool
+    match:
+      - pattern: verbose
+        platform: bash
+        run: echo hi
+    command:
+      sub:
+        short: nested
+script:
+  build: go build ./...
+`), &file))
+	groups := listGroups(&file, "aliax.yaml")
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "command", groups[0].Kind)
+	assert.Equal(t, "script", groups[1].Kind)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, writeListTable(buf, groups))
+	assert.Equal(t, `aliax.yaml
+  KIND     NAME    SHORT      FLAGS    PLATFORMS
+  command  hi      say hello  verbose  bash
+  command  hi sub  nested              all
+  script   build                       all
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, writeListTree(buf, groups))
+	assert.Equal(t, `aliax.yaml
+├── commands
+│   └── hi: say hello [bash]
+│       ├── -v, --verbose
+│       └── sub: nested [all]
+└── scripts
+    └── build [all]
+`, buf.String())
+}
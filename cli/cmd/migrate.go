@@ -0,0 +1,115 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/aos"
+	"aliax/internal/cfg"
+	"aliax/internal/style"
+	"aliax/internal/text"
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/caarlos0/log"
+	"github.com/spf13/cobra"
+)
+
+// migrateCmdParameter stores parameters for the "migrate" command.
+type migrateCmdParameter struct {
+	yes   bool
+	check bool
+}
+
+var (
+	migrateParameter migrateCmdParameter
+	migrateCmd       = &cobra.Command{
+		Use:   "migrate [file...]",
+		Short: "Upgrade the aliax configuration to the current schema version",
+		Long: `The "migrate" command upgrades configuration files written for an older schema version.
+Only the version of a file is edited when the upgrade changes nothing else, otherwise the file is
+rewritten with its comments, the order of its keys and its indentation, which TOML files can't keep
+and have to be migrated by hand. The changes are shown as a diff and applied once confirmed. Without arguments, the configuration and the files it includes are migrated.`,
+		Example: "  aliax migrate\n  aliax migrate --check\n  aliax migrate -y team/aliax.yaml",
+		Run: func(cmd *cobra.Command, args []string) {
+			files := args
+			if len(files) == 0 {
+				files = []string{config}
+				if file, err := cfg.Load(config); err == nil {
+					files = file.Files()
+				}
+			}
+
+			outdated := 0
+			for _, name := range files {
+				data, err := aos.ReadFile(name)
+				if err != nil {
+					log.WithError(err).Fatal("fail to read file")
+				}
+				migrated, applied, err := cfg.Migrate(name, data)
+				if err != nil {
+					log.WithError(err).WithField("file", name).Fatal("fail to migrate file")
+				}
+				if len(applied) == 0 {
+					log.WithField("file", name).Infof("already at version %d", cfg.CurrentVersion)
+					continue
+				}
+				outdated++
+
+				log.WithField("file", name).Infof("upgrading to version %d", cfg.CurrentVersion)
+				log.IncreasePadding()
+				for _, m := range applied {
+					log.Infof("%d → %d: %s", m.From, m.From+1, m.Description)
+				}
+				log.DecreasePadding()
+				printDiff(string(data), string(migrated))
+
+				if migrateParameter.check || (!migrateParameter.yes && !confirm(fmt.Sprintf("apply the migration to %s?", name))) {
+					continue
+				}
+				if err = os.WriteFile(name, migrated, 0644); err != nil {
+					log.WithError(err).Fatal("writing file")
+				}
+				log.WithField("file", name).Info("configuration migrated")
+			}
+			if migrateParameter.check && outdated > 0 {
+				log.WithField("suggestion", fmt.Sprintf("run %s to apply the changes", style.Keyword("aliax migrate"))).
+					Errorf("%d file(s) need to be migrated", outdated)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func init() {
+	aliaxCmd.AddCommand(migrateCmd)
+	migrateCmd.PersistentFlags().BoolVarP(&migrateParameter.yes, "yes", "y", false, "Apply the migration without asking for confirmation")
+	migrateCmd.PersistentFlags().BoolVar(&migrateParameter.check, "check", false, "Only show the changes, exiting with a non-zero status when a file needs to be migrated")
+}
+
+func printDiff(before, after string) {
+	for _, line := range text.Diff(before, after, 2) {
+		switch line[0] {
+		case '+':
+			line = style.Added(line)
+		case '-':
+			line = style.Removed(line)
+		case '@':
+			line = style.Bold(line)
+		}
+		fmt.Println(line)
+	}
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/cfg"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/caarlos0/log"
+)
+
+// FatalConfig logs err and exits. The problems found in a configuration are
+// listed one by one, each followed by an excerpt of the offending lines.
+func FatalConfig(err error, msg string) {
+	var (
+		errs cfg.Errors
+		e    *cfg.Error
+	)
+	switch {
+	case errors.As(err, &errs):
+	case errors.As(err, &e):
+		errs = cfg.Errors{e}
+	default:
+		log.WithError(err).Fatal(msg)
+	}
+	log.Errorf("%s: found %d problem(s)", msg, len(errs))
+	reportErrors(errs)
+	os.Exit(1)
+}
+
+// reportErrors logs the problems of a configuration with their excerpt.
+func reportErrors(errs []*cfg.Error) {
+	log.IncreasePadding()
+	for _, e := range errs {
+		log.Error(e.Error())
+		if excerpt := e.Excerpt(); len(excerpt) > 0 {
+			fmt.Fprintln(os.Stderr, excerpt)
+		}
+	}
+	log.DecreasePadding()
+}
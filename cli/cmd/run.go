@@ -0,0 +1,106 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/cfg"
+	"aliax/internal/runner"
+	"aliax/internal/style"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/caarlos0/log"
+	"github.com/spf13/cobra"
+)
+
+// ScriptOptions configures how scripts are run,
+// it's set from the global flags of aliax.
+var ScriptOptions struct {
+	Verbose  bool
+	Dry      bool
+	Parallel bool
+	Jobs     int
+	Force    bool
+	Why      bool
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <script> [args...]",
+	Short: "Run a script of the aliax configuration",
+	Long: `The "run" command runs the named script after its dependencies, the remaining arguments are passed
+to the script as {{.args}} and {{.argsQuoted}}. Scripts can also be run as "aliax <script>" as long as
+no built-in command has the same name, "aliax run" always refers to the script.
+With the global --parallel flag, every argument is a script to run concurrently.
+The global flags such as --force and --dry must be placed before the script, the arguments following
+-- are passed to the script as is.`,
+	Example: "  aliax run build\n  aliax run test ./... -v\n  aliax --parallel run lint test\n  aliax --force run test -- --force",
+	// the arguments after the script belong to it
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+			cmd.Help()
+			return
+		}
+		file, err := cfg.Load(config)
+		if err != nil {
+			FatalConfig(err, "fail to parse file")
+		}
+		RunScripts(file, args)
+	},
+}
+
+func init() {
+	aliaxCmd.AddCommand(runCmd)
+}
+
+// RunScripts runs the script named by the first argument with the remaining
+// arguments, or every named script concurrently in parallel mode.
+// It exits when a script is unknown or fails.
+func RunScripts(file *cfg.Aliax, args []string) {
+	names := args[:1]
+	if ScriptOptions.Parallel {
+		names = args
+	}
+	for _, name := range names {
+		if _, ok := file.Script[name]; !ok {
+			log.WithField("target", cfg.Name()).Fatalf("unknown script: %s", name)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	r := runner.New(file)
+	r.Dry = ScriptOptions.Dry
+	r.Jobs = ScriptOptions.Jobs
+	r.Force = ScriptOptions.Force
+	r.Why = ScriptOptions.Why
+	if ScriptOptions.Parallel {
+		if err := r.RunParallel(ctx, names); err != nil {
+			log.WithError(err).Fatalf("running scripts: %s", strings.Join(names, ", "))
+		}
+		return
+	}
+	r.Args = scriptArgs(args[1:])
+	if err := r.Run(ctx, names[0]); err != nil {
+		log.WithError(err).Fatalf("running script: %s", names[0])
+	}
+}
+
+// scriptArgs returns the arguments of the script, rejecting global flags before --.
+func scriptArgs(args []string) []string {
+	for i, arg := range args {
+		if arg == "--" {
+			return append(args[:i:i], args[i+1:]...)
+		}
+		name, _, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if strings.HasPrefix(arg, "--") && (aliaxCmd.PersistentFlags().Lookup(name) != nil || scriptFlags.Lookup(name) != nil) {
+			log.WithField("suggestion", fmt.Sprintf("place it before the script, or after %s to pass it to the script", style.Keyword("--"))).
+				Fatalf("global flag %s must be placed before the script", arg)
+		}
+	}
+	return args
+}
@@ -0,0 +1,23 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptArgs(t *testing.T) {
+	assert.Equal(t, []string{"./...", "-v"}, scriptArgs([]string{"./...", "-v"}))
+	assert.Equal(t, []string{"a", "--force"}, scriptArgs([]string{"a", "--", "--force"}))
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	defer func() { ScriptOptions.Force = false }()
+	args, err := ParseGlobalFlags([]string{"--force", "build", "--force"})
+	assert.NoError(t, err)
+	assert.True(t, ScriptOptions.Force)
+	assert.Equal(t, []string{"build", "--force"}, args)
+}
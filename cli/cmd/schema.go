@@ -0,0 +1,50 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/aos"
+	"aliax/internal/cfg"
+	"encoding/json"
+
+	"github.com/caarlos0/log"
+	"github.com/spf13/cobra"
+)
+
+// schemaCmdParameter stores parameters for the "schema" command.
+type schemaCmdParameter struct {
+	output string
+}
+
+var (
+	schemaParameter schemaCmdParameter
+	schemaCmd       = &cobra.Command{
+		Use:   "schema",
+		Short: "Write the JSON Schema of the aliax configuration",
+		Long: `The "schema" command writes the JSON Schema describing aliax.yaml.
+Point your editor at the generated file to get completion and diagnostics, e.g. with the YAML language server:
+  # yaml-language-server: $schema=./aliax.schema.json`,
+		Example: "  aliax schema\n  aliax schema -o .vscode/aliax.schema.json",
+		Run: func(cmd *cobra.Command, args []string) {
+			data, err := json.MarshalIndent(cfg.GenerateSchema(), "", "  ")
+			if err != nil {
+				log.WithError(err).Fatal("encoding schema")
+			}
+			fp, err := aos.Create(schemaParameter.output)
+			if err != nil {
+				log.WithError(err).Fatal("fail to create file")
+			}
+			defer fp.Close()
+			if _, err = fp.Write(append(data, '\n')); err != nil {
+				log.WithError(err).Fatal("writing schema")
+			}
+			log.WithField("file", schemaParameter.output).Info("schema written")
+		},
+	}
+)
+
+func init() {
+	aliaxCmd.AddCommand(schemaCmd)
+	schemaCmd.PersistentFlags().StringVarP(&schemaParameter.output, "output", "o", "aliax.schema.json", "Path of the generated schema file")
+}
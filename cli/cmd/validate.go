@@ -0,0 +1,44 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/cfg"
+	"os"
+
+	"github.com/caarlos0/log"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check the aliax configuration for mistakes",
+	Long: `The "validate" command decodes the aliax configuration strictly and checks it against the JSON Schema.
+It reports unknown keys, unsupported flag types and platforms, match patterns naming undeclared flags
+and duplicate aliases, and exits with a non-zero status when any problem is found.
+Files pulled in by the include section are checked as well.`,
+	Example: "  aliax validate\n  aliax validate aliax.dev.yaml",
+	Args:    cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := config
+		if len(args) > 0 {
+			name = args[0]
+		}
+		errs, err := cfg.ValidateFile(name)
+		if err != nil {
+			log.WithError(err).Fatal("fail to read file")
+		}
+		if len(errs) == 0 {
+			log.WithField("file", name).Info("configuration is valid")
+			return
+		}
+		log.WithField("file", name).Errorf("found %d problem(s)", len(errs))
+		reportErrors(errs)
+		os.Exit(1)
+	},
+}
+
+func init() {
+	aliaxCmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/cfg"
+	"aliax/internal/runner"
+	"context"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/caarlos0/log"
+	"github.com/spf13/cobra"
+)
+
+// watchCmdParameter stores parameters for the "watch" command.
+type watchCmdParameter struct {
+	interval time.Duration
+	debounce time.Duration
+}
+
+var (
+	watchParameter watchCmdParameter
+	watchCmd       = &cobra.Command{
+		Use:   "watch <script> [args...]",
+		Short: "Run a script again whenever the files it watches change",
+		Long: `The "watch" command runs the script and runs it again, along with its dependencies, whenever a file
+matched by its watch patterns changes, or by its sources when it declares no watch patterns, or anywhere
+in the workspace otherwise. The files ignored by the .gitignore of the workspace root, by the ignore list
+of the script and the files it generates never trigger a run. A burst of changes triggers a single run,
+the previous run is terminated with the processes it started before the script restarts.
+The arguments following the script are passed to it the way "aliax run" does.`,
+		Example: "  aliax watch build\n  aliax watch --debounce 1s test ./...",
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			file, err := cfg.Load(config)
+			if err != nil {
+				FatalConfig(err, "fail to parse file")
+			}
+			if _, ok := file.Script[args[0]]; !ok {
+				log.WithField("target", config).Fatalf("unknown script: %s", args[0])
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			r := runner.New(file)
+			r.Dry = ScriptOptions.Dry
+			r.Jobs = ScriptOptions.Jobs
+			r.Force = ScriptOptions.Force
+			r.Why = ScriptOptions.Why
+			r.Args = scriptArgs(args[1:])
+			r.Interval = watchParameter.interval
+			r.Debounce = watchParameter.debounce
+			if err = r.Watch(ctx, args[0]); err != nil {
+				log.WithError(err).Fatalf("watching script: %s", args[0])
+			}
+		},
+	}
+)
+
+func init() {
+	aliaxCmd.AddCommand(watchCmd)
+	// the flags following the script belong to it
+	watchCmd.Flags().SetInterspersed(false)
+	for _, name := range []string{"dry", "jobs", "force", "why"} {
+		watchCmd.Flags().AddFlag(scriptFlags.Lookup(name))
+	}
+	watchCmd.Flags().DurationVar(&watchParameter.interval, "interval", 500*time.Millisecond, "How often the watched files are polled")
+	watchCmd.Flags().DurationVar(&watchParameter.debounce, "debounce", 200*time.Millisecond, "How long the files must be unchanged before the script runs again")
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cmd
+
+import (
+	"aliax/internal/aos"
+	"aliax/internal/cfg"
+	"aliax/internal/style"
+	"fmt"
+	"path/filepath"
+
+	"github.com/caarlos0/log"
+	"github.com/spf13/cobra"
+)
+
+// workCmdParameter stores parameters for the "work" command.
+type workCmdParameter struct {
+	force bool
+	drop  bool
+}
+
+var (
+	workParameter workCmdParameter
+	workCmd       = &cobra.Command{
+		Use:   "work",
+		Short: "Manage the aliax.work file of a multi-config workspace",
+		Long: `The "work" command manages aliax.work, which lists the aliax configurations making up the workspace
+(e.g. per-team or per-subproject files) and selects the active one, much like go.work does for Go modules.`,
+		Example: "  aliax work init aliax.yaml team/aliax.yaml\n  aliax work switch team/aliax.yaml\n  aliax work list",
+	}
+	workInitCmd = &cobra.Command{
+		Use:   "init [config...]",
+		Short: "Create aliax.work in the current directory",
+		Long: `The "init" command creates aliax.work in the current directory using the given configurations.
+The first configuration becomes the active one. Without arguments, the aliax configuration
+of the current directory (aliax.yaml, aliax.yml, aliax.json or aliax.toml) is used.`,
+		Example: "  aliax work init\n  aliax work init aliax.yaml cli/aliax.yaml",
+		Run: func(cmd *cobra.Command, args []string) {
+			if ok, _ := aos.Exist("aliax.work"); ok && !workParameter.force {
+				log.WithField("suggestion", fmt.Sprintf("add %s flag to overwrite it", style.Keyword("-f"))).Fatal("aliax.work already exists")
+			}
+			if len(args) == 0 {
+				args = []string{"aliax.yaml"}
+				if name := cfg.Lookup(".", "aliax"); len(name) > 0 {
+					args = []string{name}
+				}
+			}
+			w := &cfg.Work{}
+			for _, arg := range args {
+				w.AddUse(workPath(".", arg))
+			}
+			w.Active = w.Use[0]
+			if err := cfg.WriteWork("aliax.work", w); err != nil {
+				log.WithError(err).Fatal("writing aliax.work")
+			}
+			log.WithField("active", w.Active).Info("aliax.work created")
+		},
+	}
+	workUseCmd = &cobra.Command{
+		Use:     "use <config...>",
+		Short:   "Add configurations to the workspace, or drop them with --drop",
+		Example: "  aliax work use team/aliax.yaml\n  aliax work use --drop team/aliax.yaml",
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name, w := readWork()
+			dir := filepath.Dir(name)
+			paths := []string{}
+			for _, arg := range args {
+				if workParameter.drop {
+					paths = append(paths, relPath(dir, arg))
+				} else {
+					paths = append(paths, workPath(dir, arg))
+				}
+			}
+			if workParameter.drop {
+				w.DropUse(paths...)
+			} else {
+				w.AddUse(paths...)
+			}
+			if err := cfg.WriteWork(name, w); err != nil {
+				log.WithError(err).Fatal("writing aliax.work")
+			}
+			log.WithField("use", w.Use).Info("aliax.work updated")
+		},
+	}
+	workSwitchCmd = &cobra.Command{
+		Use:     "switch <config>",
+		Short:   "Select the active configuration of the workspace",
+		Example: "  aliax work switch aliax.dev.yaml",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name, w := readWork()
+			path := workPath(filepath.Dir(name), args[0])
+			if !w.Uses(path) {
+				log.WithField("config", path).
+					WithField("suggestion", fmt.Sprintf("run %s first", style.Keyword("aliax work use "+path))).
+					Fatal("configuration is not used by the workspace")
+			}
+			w.Active = path
+			if err := cfg.WriteWork(name, w); err != nil {
+				log.WithError(err).Fatal("writing aliax.work")
+			}
+			log.WithField("active", path).Info("switched configuration")
+		},
+	}
+	workListCmd = &cobra.Command{
+		Use:     "list",
+		Short:   "List the configurations used by the workspace",
+		Example: "  aliax work list",
+		Run: func(cmd *cobra.Command, args []string) {
+			name, w := readWork()
+			log.Infof("configurations used by %s", name)
+			log.IncreasePadding()
+			for _, use := range w.Use {
+				if filepath.Clean(use) == filepath.Clean(w.Active) {
+					log.WithField("active", true).Info(use)
+				} else {
+					log.Info(use)
+				}
+			}
+			log.DecreasePadding()
+		},
+	}
+)
+
+func init() {
+	aliaxCmd.AddCommand(workCmd)
+	workCmd.AddCommand(workInitCmd, workUseCmd, workSwitchCmd, workListCmd)
+	workInitCmd.PersistentFlags().BoolVarP(&workParameter.force, "force", "f", false, "Overwrite an existing aliax.work")
+	workUseCmd.PersistentFlags().BoolVar(&workParameter.drop, "drop", false, "Remove the configurations from the workspace instead")
+}
+
+// readWork reads the aliax.work file of the workspace, failing when there is none.
+func readWork() (string, *cfg.Work) {
+	name := cfg.WorkFile()
+	if len(name) == 0 {
+		log.WithField("suggestion", fmt.Sprintf("run %s to create one", style.Keyword("aliax work init"))).Fatal("aliax.work not found")
+	}
+	w, err := cfg.ReadWork(name)
+	if err != nil {
+		log.WithError(err).Fatal("fail to parse aliax.work")
+	}
+	return name, w
+}
+
+// workPath converts a path given on the command line into a path relative to
+// the directory of aliax.work, checking that the configuration exists.
+func workPath(dir, path string) string {
+	if ok, _ := aos.Exist(path); !ok {
+		log.WithField("config", path).Fatal("configuration not found")
+	}
+	return relPath(dir, path)
+}
+
+func relPath(dir, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		log.WithError(err).Fatal("invalid path")
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		log.WithError(err).Fatal("invalid path")
+	}
+	if rel, err := filepath.Rel(absDir, abs); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return abs
+}
@@ -3,6 +3,7 @@ module aliax
 go 1.23.5
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/ansurfen/globalenv v0.0.0-20250217130115-7792f11c68f1
 	github.com/caarlos0/log v0.4.8
 	github.com/charmbracelet/lipgloss v1.0.0
@@ -10,6 +11,7 @@ require (
 	github.com/google/shlex v0.0.0-20191202100458-e7afc7fbc510
 	github.com/muesli/termenv v0.15.2
 	github.com/spf13/cobra v1.8.1
+	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.10.0
 	golang.org/x/text v0.22.0
 	gopkg.in/yaml.v3 v3.0.1
@@ -25,6 +27,5 @@ require (
 	github.com/mattn/go-runewidth v0.0.15 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/sys v0.23.0 // indirect
 )
@@ -233,16 +233,29 @@ type (
 		Func Expr
 		Recv []Expr
 	}
+
+	// FuncStmt represents a function definition `name() { ... }`.
+	FuncStmt struct {
+		Name string
+		Body *BlockStmt
+	}
+
+	// SubshellStmt represents a list of statements run in a subshell `( ... )`.
+	SubshellStmt struct {
+		Body *BlockStmt
+	}
 )
 
-func (*IfStmt) stmtNode()     {}
-func (*ForStmt) stmtNode()    {}
-func (*ExprStmt) stmtNode()   {}
-func (*BlockStmt) stmtNode()  {}
-func (*SwitchStmt) stmtNode() {}
-func (*CaseStmt) stmtNode()   {}
-func (*AssignStmt) stmtNode() {}
-func (*CallStmt) stmtNode()   {}
+func (*IfStmt) stmtNode()       {}
+func (*ForStmt) stmtNode()      {}
+func (*ExprStmt) stmtNode()     {}
+func (*BlockStmt) stmtNode()    {}
+func (*SwitchStmt) stmtNode()   {}
+func (*CaseStmt) stmtNode()     {}
+func (*AssignStmt) stmtNode()   {}
+func (*CallStmt) stmtNode()     {}
+func (*FuncStmt) stmtNode()     {}
+func (*SubshellStmt) stmtNode() {}
 
 // IfStatement creates a new if statement with an empty body.
 func IfStatement() *IfStmt {
@@ -259,6 +272,16 @@ func ForStatement(init, cond, post Expr) *ForStmt {
 	}
 }
 
+// FuncStatement creates a new function definition with an empty body.
+func FuncStatement(name string) *FuncStmt {
+	return &FuncStmt{Name: name, Body: &BlockStmt{}}
+}
+
+// SubshellStatement creates a new subshell running the given list of statements.
+func SubshellStatement(stmts ...Stmt) *SubshellStmt {
+	return &SubshellStmt{Body: &BlockStmt{List: stmts}}
+}
+
 // BlockStatement creates a new block statement with the given list of statements.
 func BlockStatement(stmts ...Stmt) *BlockStmt {
 	return &BlockStmt{List: stmts}
@@ -34,7 +34,7 @@ func print(w io.Writer, node Node, space string) {
 				print(w, el.Body, space)
 				node.Else = el.Else
 			case *BlockStmt:
-				fmt.Fprint(w, space+"else")
+				fmt.Fprintln(w, space+"else")
 				print(w, el, space)
 				node.Else = nil
 			}
@@ -72,6 +72,14 @@ func print(w io.Writer, node Node, space string) {
 			recv = append(recv, r.String())
 		}
 		fmt.Fprintf(w, space+"%s %s\n", node.Func, strings.Join(recv, " "))
+	case *FuncStmt:
+		fmt.Fprintf(w, space+"%s() {\n", node.Name)
+		print(w, node.Body, space)
+		fmt.Fprintln(w, space+"}")
+	case *SubshellStmt:
+		fmt.Fprintln(w, space+"(")
+		print(w, node.Body, space)
+		fmt.Fprintln(w, space+")")
 	case *Comment:
 		fmt.Fprintf(w, space+"#%s\n", node.Text)
 	}
@@ -0,0 +1,352 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package fishast
+
+import (
+	token "aliax/internal/token/fish"
+	"fmt"
+	"strings"
+)
+
+// Node represents any node in the abstract syntax tree (AST).
+type Node interface{}
+
+// Expr is the interface for all expression nodes in the AST.
+// Any type that implements `exprNode()` is considered an expression node.
+type Expr interface {
+	Node
+	exprNode()
+
+	String() string
+}
+
+// Stmt is the interface for all statement nodes in the AST.
+// Any type that implements `stmtNode()` is considered a statement node.
+type Stmt interface {
+	Node
+	stmtNode()
+}
+
+// Raw creates a raw expression from a string (like an identifier).
+func Raw(script string) Expr {
+	return &Ident{Name: script}
+}
+
+// RawStmt creates a raw statement from a string (like an expression statement).
+func RawStmt(s string) Stmt {
+	return &ExprStmt{X: &Ident{Name: s}}
+}
+
+type (
+	// BinaryExpr represents two conditions joined by and or or.
+	BinaryExpr struct {
+		X  Expr
+		Op token.Token
+		Y  Expr
+	}
+
+	// NotExpr represents a negated condition.
+	NotExpr struct {
+		X Expr
+	}
+
+	// TestExpr represents a comparison done by the test builtin.
+	TestExpr struct {
+		X  Expr
+		Op token.Token
+		Y  Expr
+	}
+
+	// RefExpr represents a reference to a variable, Quoted keeps an empty list as an empty string.
+	RefExpr struct {
+		X      Expr
+		Quoted bool
+	}
+
+	// IndexExpr represents an element of a list, fish lists are indexed from 1.
+	IndexExpr struct {
+		X   Expr
+		Key Expr
+	}
+
+	// BasicExpr represents a basic expression with a type and a value.
+	BasicExpr struct {
+		Kind  token.Token
+		Value string
+	}
+
+	// Ident represents an identifier (like a variable or function name).
+	Ident struct {
+		Name string
+	}
+)
+
+func (*BinaryExpr) exprNode() {}
+func (*NotExpr) exprNode()    {}
+func (*TestExpr) exprNode()   {}
+func (*RefExpr) exprNode()    {}
+func (*IndexExpr) exprNode()  {}
+func (*BasicExpr) exprNode()  {}
+func (*Ident) exprNode()      {}
+
+// String joins the conditions, an operand joined by the other operator is
+// grouped in a begin block since and and or have the same precedence.
+func (e *BinaryExpr) String() string {
+	group := func(x Expr) string {
+		if b, ok := x.(*BinaryExpr); ok && b.Op != e.Op {
+			return fmt.Sprintf("begin; %s; end", b)
+		}
+		return x.String()
+	}
+	return fmt.Sprintf("%s; %s %s", group(e.X), e.Op, group(e.Y))
+}
+
+func (e *NotExpr) String() string {
+	if _, ok := e.X.(*BinaryExpr); ok {
+		return fmt.Sprintf("not begin; %s; end", e.X)
+	}
+	return fmt.Sprintf("not %s", e.X)
+}
+
+func (e *TestExpr) String() string {
+	return fmt.Sprintf("test %s %s %s", e.X, e.Op, e.Y)
+}
+
+func (e *RefExpr) String() string {
+	if e.Quoted {
+		return fmt.Sprintf(`"$%s"`, e.X)
+	}
+	return fmt.Sprintf("$%s", e.X)
+}
+
+func (e *IndexExpr) String() string {
+	return fmt.Sprintf("%s[%s]", e.X, e.Key)
+}
+
+func (e *BasicExpr) String() string {
+	switch e.Kind {
+	case token.STRING:
+		return Quote(e.Value)
+	default:
+		return e.Value
+	}
+}
+
+func (e *Ident) String() string {
+	return e.Name
+}
+
+// Quote returns s as a single-quoted fish string.
+func Quote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// BinaryExpression creates a new binary expression with the given operands and operator.
+func BinaryExpression(x Expr, op token.Token, y Expr) *BinaryExpr {
+	return &BinaryExpr{X: x, Op: op, Y: y}
+}
+
+// NotExpression negates the condition.
+func NotExpression(x Expr) *NotExpr {
+	return &NotExpr{X: x}
+}
+
+// TestExpression creates a new comparison of the test builtin.
+func TestExpression(x Expr, op token.Token, y Expr) *TestExpr {
+	return &TestExpr{X: x, Op: op, Y: y}
+}
+
+// RefRaw creates a reference to the named variable.
+func RefRaw(name string) *RefExpr {
+	return &RefExpr{X: &Ident{Name: name}}
+}
+
+// QuotedRef creates a double-quoted reference to the named variable.
+func QuotedRef(name string) *RefExpr {
+	return &RefExpr{X: &Ident{Name: name}, Quoted: true}
+}
+
+// Number creates a new basic expression representing a number.
+func Number(n int) *BasicExpr {
+	return &BasicExpr{
+		Kind:  token.NUMBER,
+		Value: fmt.Sprintf("%d", n),
+	}
+}
+
+// String creates a new basic expression representing a string.
+func String(s string) *BasicExpr {
+	return &BasicExpr{
+		Kind:  token.STRING,
+		Value: s,
+	}
+}
+
+var (
+	TRUE  = &BasicExpr{Kind: token.BOOL, Value: "true"}
+	FALSE = &BasicExpr{Kind: token.BOOL, Value: "false"}
+)
+
+// Identifier creates a new identifier expression with the given name.
+func Identifier(name string) *Ident {
+	return &Ident{Name: name}
+}
+
+type (
+	// IfStmt represents an `if` statement, which has a condition, a body, and an optional else branch.
+	IfStmt struct {
+		Cond Expr
+		Body *BlockStmt
+		Else Stmt
+	}
+
+	// ForStmt represents a `for` loop over the values of a list.
+	ForStmt struct {
+		Var  string
+		List []Expr
+		Body *BlockStmt
+	}
+
+	// FuncStmt represents a function definition, Options are the options
+	// of the function builtin such as --description.
+	FuncStmt struct {
+		Name    string
+		Options []string
+		Body    *BlockStmt
+	}
+
+	// ExprStmt represents a statement that contains a single expression.
+	ExprStmt struct {
+		X Expr
+	}
+
+	// BlockStmt represents a block of statements closed by `end`.
+	BlockStmt struct {
+		List []Stmt
+	}
+
+	// SwitchStmt represents a `switch` statement with cases and a default block.
+	SwitchStmt struct {
+		Cond    Expr
+		Cases   []*CaseStmt
+		Default *CaseStmt
+	}
+
+	// CaseStmt represents a `case` statement in a switch, with its patterns and a body.
+	CaseStmt struct {
+		Patterns []Expr
+		Body     *BlockStmt
+	}
+
+	// SetStmt represents a `set` statement, Scope holds its options such as -l or -gx.
+	SetStmt struct {
+		Scope  string
+		Name   Expr
+		Values []Expr
+	}
+
+	// CallStmt represents a command call with a function name and arguments.
+	CallStmt struct {
+		Func Expr
+		Recv []Expr
+	}
+)
+
+func (*IfStmt) stmtNode()     {}
+func (*ForStmt) stmtNode()    {}
+func (*FuncStmt) stmtNode()   {}
+func (*ExprStmt) stmtNode()   {}
+func (*BlockStmt) stmtNode()  {}
+func (*SwitchStmt) stmtNode() {}
+func (*CaseStmt) stmtNode()   {}
+func (*SetStmt) stmtNode()    {}
+func (*CallStmt) stmtNode()   {}
+
+// IfStatement creates a new if statement with an empty body.
+func IfStatement() *IfStmt {
+	return &IfStmt{Body: &BlockStmt{}}
+}
+
+// ForStatement creates a new `for` statement iterating over list.
+func ForStatement(v string, list ...Expr) *ForStmt {
+	return &ForStmt{
+		Var:  v,
+		List: list,
+		Body: &BlockStmt{},
+	}
+}
+
+// FuncStatement creates a new function definition with an empty body.
+func FuncStatement(name string, options ...string) *FuncStmt {
+	return &FuncStmt{
+		Name:    name,
+		Options: options,
+		Body:    &BlockStmt{},
+	}
+}
+
+// BlockStatement creates a new block statement with the given list of statements.
+func BlockStatement(stmts ...Stmt) *BlockStmt {
+	return &BlockStmt{List: stmts}
+}
+
+// Append adds more statements to the end of the block.
+func (b *BlockStmt) Append(stmts ...Stmt) {
+	b.List = append(b.List, stmts...)
+}
+
+// SetDefault sets the default case for the switch statement.
+func (s *SwitchStmt) SetDefault(b *BlockStmt) {
+	s.Default = &CaseStmt{Body: b}
+}
+
+// CaseStatement creates a new case statement with the given patterns.
+func CaseStatement(patterns ...Expr) *CaseStmt {
+	return &CaseStmt{
+		Patterns: patterns,
+		Body:     &BlockStmt{},
+	}
+}
+
+// SetStatement creates a new set statement assigning the values to the named variable.
+func SetStatement(scope, name string, values ...Expr) *SetStmt {
+	return &SetStmt{
+		Scope:  scope,
+		Name:   &Ident{Name: name},
+		Values: values,
+	}
+}
+
+// CallStatement creates a new command call statement with the given function name and arguments.
+func CallStatement(name string, args ...string) *CallStmt {
+	recv := []Expr{}
+	for _, a := range args {
+		recv = append(recv, &Ident{Name: a})
+	}
+	return &CallStmt{
+		Func: &Ident{Name: name},
+		Recv: recv,
+	}
+}
+
+// File represents a collection of statements (like a program or a script).
+type File struct {
+	Stmts []Stmt
+}
+
+func (f *File) Append(stmts ...Stmt) {
+	f.Stmts = append(f.Stmts, stmts...)
+}
+
+// Comment represents a comment in the code.
+type Comment struct {
+	Text string
+}
+
+func (*Comment) stmtNode() {}
+
+// Docs creates a new comment node with the given text.
+func Docs(text string) *Comment {
+	return &Comment{Text: text}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package fishast
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Print writes the string representation of the given AST node to the provided writer.
+func Print(node Node, w io.Writer) {
+	print(w, node, "")
+}
+
+// indent is the indentation of fish_indent.
+const indent = "    "
+
+func print(w io.Writer, node Node, space string) {
+	switch node := node.(type) {
+	case *File:
+		for _, s := range node.Stmts {
+			print(w, s, space)
+		}
+	case *BlockStmt:
+		for _, s := range node.List {
+			print(w, s, space+indent)
+		}
+	case *IfStmt:
+		fmt.Fprintf(w, space+"if %s\n", node.Cond)
+		print(w, node.Body, space)
+		for el := node.Else; el != nil; {
+			switch e := el.(type) {
+			case *IfStmt:
+				fmt.Fprintf(w, space+"else if %s\n", e.Cond)
+				print(w, e.Body, space)
+				el = e.Else
+			case *BlockStmt:
+				fmt.Fprintln(w, space+"else")
+				print(w, e, space)
+				el = nil
+			}
+		}
+		fmt.Fprintln(w, space+"end")
+	case *ForStmt:
+		list := []string{}
+		for _, e := range node.List {
+			list = append(list, e.String())
+		}
+		fmt.Fprintf(w, space+"for %s in %s\n", node.Var, strings.Join(list, " "))
+		print(w, node.Body, space)
+		fmt.Fprintln(w, space+"end")
+	case *FuncStmt:
+		fmt.Fprintf(w, space+"%s\n", strings.Join(append([]string{"function", node.Name}, node.Options...), " "))
+		print(w, node.Body, space)
+		fmt.Fprintln(w, space+"end")
+	case *SwitchStmt:
+		fmt.Fprintf(w, space+"switch %s\n", node.Cond)
+		for _, c := range node.Cases {
+			patterns := []string{}
+			for _, p := range c.Patterns {
+				patterns = append(patterns, p.String())
+			}
+			fmt.Fprintf(w, space+indent+"case %s\n", strings.Join(patterns, " "))
+			print(w, c.Body, space+indent)
+		}
+		if node.Default != nil {
+			fmt.Fprintln(w, space+indent+"case '*'")
+			print(w, node.Default.Body, space+indent)
+		}
+		fmt.Fprintln(w, space+"end")
+	case *ExprStmt:
+		fmt.Fprintf(w, space+"%s\n", node.X)
+	case *SetStmt:
+		words := []string{"set"}
+		if len(node.Scope) > 0 {
+			words = append(words, node.Scope)
+		}
+		words = append(words, node.Name.String())
+		for _, v := range node.Values {
+			words = append(words, v.String())
+		}
+		fmt.Fprintf(w, space+"%s\n", strings.Join(words, " "))
+	case *CallStmt:
+		words := []string{node.Func.String()}
+		for _, r := range node.Recv {
+			words = append(words, r.String())
+		}
+		fmt.Fprintf(w, space+"%s\n", strings.Join(words, " "))
+	case *Comment:
+		fmt.Fprintf(w, space+"#%s\n", node.Text)
+	}
+}
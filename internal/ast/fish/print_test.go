@@ -0,0 +1,69 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package fishast
+
+import (
+	token "aliax/internal/token/fish"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `'it\'s a \\ path'`, Quote(`it's a \ path`))
+}
+
+func TestBinaryExpression(t *testing.T) {
+	x := TestExpression(QuotedRef("a"), token.EQ, String("b"))
+	or := BinaryExpression(Raw("set -q c"), token.OR, Raw("set -q d"))
+	assert.Equal(t, `test "$a" = 'b'; and begin; set -q c; or set -q d; end`, BinaryExpression(x, token.AND, or).String())
+	assert.Equal(t, `not begin; set -q c; or set -q d; end`, NotExpression(or).String())
+}
+
+func TestPrint(t *testing.T) {
+	var buf strings.Builder
+
+	fn := FuncStatement("hi", "--description", Quote("say hi"))
+	ifStmt := IfStatement()
+	ifStmt.Cond = Raw("set -q argv[1]")
+	ifStmt.Body.Append(CallStatement("echo", `"hi $argv[1]"`))
+	elseIf := IfStatement()
+	elseIf.Cond = TestExpression(QuotedRef("USER"), token.EQ, String("root"))
+	elseIf.Body.Append(CallStatement("echo", "$USER"))
+	elseIf.Else = BlockStatement(CallStatement("return", "1"))
+	ifStmt.Else = elseIf
+	forStmt := ForStatement("x", Raw("a"), Raw("b"))
+	forStmt.Body.Append(SetStatement("-l", "y", RefRaw("x")))
+	switchStmt := &SwitchStmt{Cond: RefRaw("y")}
+	c := CaseStatement(String("a"))
+	c.Body.Append(RawStmt("true"))
+	switchStmt.Cases = append(switchStmt.Cases, c)
+	switchStmt.SetDefault(BlockStatement(RawStmt("false")))
+	fn.Body.Append(ifStmt, forStmt, switchStmt)
+	file := &File{}
+	file.Append(Docs(" generated"), fn)
+
+	Print(file, &buf)
+	assert.Equal(t, `# generated
+function hi --description 'say hi'
+    if set -q argv[1]
+        echo "hi $argv[1]"
+    else if test "$USER" = 'root'
+        echo $USER
+    else
+        return 1
+    end
+    for x in a b
+        set -l y $x
+    end
+    switch $y
+        case 'a'
+            true
+        case '*'
+            false
+    end
+end
+`, buf.String())
+}
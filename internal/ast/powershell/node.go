@@ -263,6 +263,12 @@ type (
 		Default *CaseStmt   // Default case (optional).
 	}
 
+	// TryStmt represents a try statement with a finally clause.
+	TryStmt struct {
+		Body    *BlockStmt // The guarded statements.
+		Finally *BlockStmt // The statements run once the body exits.
+	}
+
 	// BlockStmt represents a block of statements.
 	BlockStmt struct {
 		List []Stmt // List of statements in the block.
@@ -275,6 +281,7 @@ func (*AssignStmt) stmtNode() {}
 func (*IfStmt) stmtNode()     {}
 func (*ForStmt) stmtNode()    {}
 func (*SwitchStmt) stmtNode() {}
+func (*TryStmt) stmtNode()    {}
 func (*BlockStmt) stmtNode()  {}
 
 // AssignStatement creates an assignment statement.
@@ -285,6 +292,14 @@ func AssignStatement(lhs, rhs Expr) *AssignStmt {
 	}
 }
 
+// TryStatement creates a try statement with a finally clause.
+func TryStatement(body, finally *BlockStmt) *TryStmt {
+	return &TryStmt{
+		Body:    body,
+		Finally: finally,
+	}
+}
+
 // IfStatement creates an if statement with an empty body.
 func IfStatement() *IfStmt {
 	return &IfStmt{Body: &BlockStmt{}}
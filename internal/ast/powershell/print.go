@@ -41,6 +41,11 @@ func print(w io.Writer, node Node, space string) {
 				node.Else = nil
 			}
 		}
+	case *TryStmt:
+		fmt.Fprint(w, space+"try")
+		print(w, node.Body, space)
+		fmt.Fprint(w, space+"finally")
+		print(w, node.Finally, space)
 	case *ForStmt:
 		fmt.Fprintf(w, space+"for (%s; %s; %s)", node.Init, node.Cond, node.Post)
 		print(w, node.Body, space)
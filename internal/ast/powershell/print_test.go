@@ -183,3 +183,12 @@ func TestCall(t *testing.T) {
 		},
 	}, os.Stdout)
 }
+
+func TestTry(t *testing.T) {
+	var buf strings.Builder
+	Print(TryStatement(
+		BlockStatement(CallStatement(token.None, "exit")),
+		BlockStatement(CallStatement(token.None, "Pop-Location")),
+	), &buf)
+	assert.Equal(t, "try {\n  exit \n}\nfinally {\n  Pop-Location \n}\n", buf.String())
+}
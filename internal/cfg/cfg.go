@@ -6,22 +6,267 @@ package cfg
 import (
 	"aliax/internal/aos"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Flag struct {
-	Name  string   `yaml:"name"`
+	Name  string   `yaml:"name" schema:"required"`
 	Alias []string `yaml:"alias"`
-	Type  string   `yaml:"type"`
+	Type  string   `yaml:"type" schema:"required,enum=string|bool|int|float|enum|duration|list"`
 	Usage string   `yaml:"usage"`
+	// Choices lists the values accepted by an enum flag.
+	Choices []string `yaml:"choices"`
+	// Default is the value used when the flag is neither passed nor set
+	// through its environment variable, a list flag accepts a list of values.
+	Default any `yaml:"default"`
+	// Required flags must be passed or set through their environment variable.
+	Required bool `yaml:"required"`
+	// Env names the environment variable read when the flag isn't passed.
+	// A list flag splits its value on commas, a bool flag is set by true or 1.
+	Env string `yaml:"env"`
+
+	pos Pos `yaml:"-"`
+}
+
+// Defaults returns the default values of the flag as strings.
+func (f *Flag) Defaults() []string {
+	switch v := f.Default.(type) {
+	case nil:
+		return nil
+	case []any:
+		values := []string{}
+		for _, e := range v {
+			values = append(values, fmt.Sprint(e))
+		}
+		return values
+	}
+	return []string{fmt.Sprint(f.Default)}
+}
+
+// flagPatterns holds the regular expressions validating the value of typed flags.
+// They're written in the common subset of POSIX ERE, .NET and Go regular
+// expressions, so that the generated scripts and aliax agree on what is valid.
+var flagPatterns = map[string]string{
+	"int":      `^[+-]?[0-9]+$`,
+	"float":    `^[+-]?([0-9]+([.][0-9]*)?|[.][0-9]+)([eE][+-]?[0-9]+)?$`,
+	"duration": `^([0-9]+([.][0-9]+)?(ns|us|ms|s|m|h))+$`,
+}
+
+// Pattern returns the regular expression a value of the flag must match,
+// or an empty string when any value is accepted.
+func (f *Flag) Pattern() string {
+	if f.Type == "enum" {
+		choices := []string{}
+		for _, c := range f.Choices {
+			choices = append(choices, regexp.QuoteMeta(c))
+		}
+		return fmt.Sprintf("^(%s)$", strings.Join(choices, "|"))
+	}
+	return flagPatterns[f.Type]
+}
+
+// Expected describes the values accepted by the flag, it's used in error messages.
+func (f *Flag) Expected() string {
+	if f.Type == "enum" {
+		return fmt.Sprintf("one of %s", strings.Join(f.Choices, ", "))
+	}
+	return f.Type
+}
+
+// CheckValue reports whether the value is valid for the flag.
+func (f *Flag) CheckValue(v string) error {
+	pattern := f.Pattern()
+	if len(pattern) == 0 || regexp.MustCompile(pattern).MatchString(v) {
+		return nil
+	}
+	return fmt.Errorf("invalid value %q for flag %s: expected %s", v, f.Name, f.Expected())
+}
+
+func (f *Flag) UnmarshalYAML(value *yaml.Node) error {
+	type plain Flag
+	if err := value.Decode((*plain)(f)); err != nil {
+		return err
+	}
+	f.pos = posOf(value)
+	return nil
+}
+
+// Arg declares a named positional argument of a command. Positionals are
+// assigned in order from the arguments left over once the flags are parsed.
+type Arg struct {
+	Name     string `yaml:"name" schema:"required"`
+	Usage    string `yaml:"usage"`
+	Required bool   `yaml:"required"`
+	// Variadic collects the remaining positionals, only the last argument can be variadic.
+	Variadic bool `yaml:"variadic"`
+
+	pos Pos `yaml:"-"`
+}
+
+func (a *Arg) UnmarshalYAML(value *yaml.Node) error {
+	type plain Arg
+	if err := value.Decode((*plain)(a)); err != nil {
+		return err
+	}
+	a.pos = posOf(value)
+	return nil
+}
+
+// String returns the argument as shown in usage lines,
+// <name> when it's required and [name] otherwise.
+func (a *Arg) String() string {
+	name := a.Name
+	if a.Variadic {
+		name += "..."
+	}
+	if a.Required {
+		return "<" + name + ">"
+	}
+	return "[" + name + "]"
 }
 
 type Case struct {
-	Pattern  any    `yaml:"pattern"`
-	Platform string `yaml:"platform"`
+	Pattern  any    `yaml:"pattern" schema:"def=Pattern"`
+	Platform string `yaml:"platform" schema:"enum=bash|fish|powershell|zsh|batch|windows|posix"`
 	Run      string `yaml:"run"`
+	// Dir is the directory the case runs in, relative to the workspace root.
+	Dir string `yaml:"dir"`
+	// When is the condition the case is selected under, see package cond.
+	When string `yaml:"when"`
+	// Priority ranks the case before the ones with a lower priority, see Command.Ranked.
+	Priority int `yaml:"priority"`
+	// Timeout, Retries and RetryDelay override the ones of the script,
+	// they're only honored when the case runs as a script. Retries is
+	// a pointer so that retries: 0 turns off the retries of the script.
+	Timeout    string `yaml:"timeout" schema:"def=Duration"`
+	Retries    *int   `yaml:"retries"`
+	RetryDelay string `yaml:"retry_delay" schema:"def=Duration"`
+
+	pos Pos `yaml:"-"`
+}
+
+func (c *Case) UnmarshalYAML(value *yaml.Node) error {
+	type plain Case
+	if err := value.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	c.pos = posOf(value)
+	return nil
+}
+
+// Names returns the flag names referenced by the pattern of the case.
+// The wildcard pattern "_" and an empty pattern yield no names,
+// the names of a map pattern are sorted.
+func (c *Case) Names() []string {
+	names := []string{}
+	switch pattern := c.Pattern.(type) {
+	case string:
+		if pattern != "_" && len(pattern) != 0 {
+			names = append(names, pattern)
+		}
+	case []any:
+		for _, v := range pattern {
+			if v, ok := v.(string); ok {
+				names = append(names, v)
+			}
+		}
+	case map[string]any:
+		names = append(names, SortedKeys(pattern)...)
+	}
+	return names
+}
+
+// Test is what a match pattern requires from a flag or an argument.
+type Test struct {
+	Name string
+	// Op is empty when the flag must be set, == when its value must equal
+	// Values[0], ~ when it must match the regular expression Values[0]
+	// and in when it must be one of Values.
+	Op     string
+	Values []string
+	Negate bool
+}
+
+// Tests returns the tests of the pattern of the case, in the order of Names.
+// A list pattern requires its flags to be set, a map pattern maps flags to
+// their test:
+//
+//	pattern: {env: prod, force: true}   # equality, or inequality with "!prod"
+//	pattern: {target: "~^linux"}        # regular expression, negated with "!~"
+//	pattern: {target: [linux, darwin]}  # membership
+//	pattern: {target: {not: [windows]}} # negation of any test
+//	pattern: {target: null}             # set, like a list pattern
+//
+// A value starting with "=" is compared as is, so that it may start with ! or ~.
+func (c *Case) Tests() ([]Test, error) {
+	pattern, ok := c.Pattern.(map[string]any)
+	if !ok {
+		tests := []Test{}
+		for _, name := range c.Names() {
+			tests = append(tests, Test{Name: name})
+		}
+		return tests, nil
+	}
+	tests := []Test{}
+	for _, name := range SortedKeys(pattern) {
+		t, err := parseTest(name, pattern[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		tests = append(tests, t)
+	}
+	return tests, nil
+}
+
+func parseTest(name string, v any) (Test, error) {
+	t := Test{Name: name}
+	switch v := v.(type) {
+	case nil:
+	case string:
+		if strings.HasPrefix(v, "!") {
+			t.Negate = true
+			v = v[1:]
+		}
+		switch {
+		case strings.HasPrefix(v, "~"):
+			t.Op, t.Values = "~", []string{v[1:]}
+			if _, err := regexp.Compile(v[1:]); err != nil {
+				return t, fmt.Errorf("invalid regular expression %q", v[1:])
+			}
+		case strings.HasPrefix(v, "="):
+			t.Op, t.Values = "==", []string{v[1:]}
+		default:
+			t.Op, t.Values = "==", []string{v}
+		}
+	case bool, int, float64:
+		t.Op, t.Values = "==", []string{fmt.Sprint(v)}
+	case []any:
+		t.Op = "in"
+		for _, e := range v {
+			switch e.(type) {
+			case string, bool, int, float64:
+				t.Values = append(t.Values, fmt.Sprint(e))
+			default:
+				return t, fmt.Errorf("expected a list of values")
+			}
+		}
+	case map[string]any:
+		x, ok := v["not"]
+		if !ok || len(v) != 1 {
+			return t, fmt.Errorf("expected a value, a list of values or {not: test}")
+		}
+		t, err := parseTest(name, x)
+		t.Negate = !t.Negate
+		return t, err
+	default:
+		return t, fmt.Errorf("expected a value, a list of values or {not: test}")
+	}
+	return t, nil
 }
 
 type Command struct {
@@ -30,11 +275,31 @@ type Command struct {
 	Long        string              `yaml:"long"`
 	Example     string              `yaml:"example"`
 	Flags       []Flag              `yaml:"flags"`
+	Args        []Arg               `yaml:"args"`
 	Match       []Case              `yaml:"match"`
 	Command     map[string]*Command `yaml:"command"`
 	Bin         string              `yaml:"bin"`
+	// Env sets environment variables for the command and its subcommands,
+	// values are templates rendered with the variables.
+	Env map[string]string `yaml:"env"`
+	// Dotenv lists the dotenv files loaded before Env, relative to the workspace root.
+	Dotenv []string `yaml:"dotenv"`
+	// Order is how the match cases of the same priority are tried: specific,
+	// the default, tries the cases testing more flags first and declared
+	// tries them in the order they're declared.
+	Order string `yaml:"order" schema:"enum=specific|declared"`
 
 	name string `yaml:"-"`
+	pos  Pos    `yaml:"-"`
+}
+
+func (c *Command) UnmarshalYAML(value *yaml.Node) error {
+	type plain Command
+	if err := value.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	c.pos = posOf(value)
+	return nil
 }
 
 func (c *Command) SetName(name string) {
@@ -47,13 +312,14 @@ func (c *Command) Name() string {
 
 func (c *Command) Preload(name string) error {
 	if !c.DisableHelp {
-		flags := map[string]struct{}{}
-		for _, flag := range c.Flags {
-			flags[flag.Name] = struct{}{}
-		}
-		if _, ok := flags["help"]; ok {
-			// add suggestion, e.g. try disable or instead of name
-			return fmt.Errorf("help flag ready exist")
+		for i, flag := range c.Flags {
+			if flag.Name == "help" {
+				return &Error{
+					Pos:  flag.pos,
+					Path: joinPath("command."+name, fmt.Sprintf("flags[%d]", i)),
+					Msg:  `flag "help" conflicts with the generated help flag, set disableHelp or rename it`,
+				}
+			}
 		}
 		c.Flags = append(c.Flags, Flag{
 			Name:  "help",
@@ -65,6 +331,29 @@ func (c *Command) Preload(name string) error {
 	return nil
 }
 
+// ArgsRange returns the minimum and maximum number of positionals accepted
+// by the command, max is -1 when the last argument is variadic.
+func (c *Command) ArgsRange() (min, max int) {
+	for _, arg := range c.Args {
+		if arg.Required {
+			min++
+		}
+		if arg.Variadic {
+			return min, -1
+		}
+	}
+	return min, len(c.Args)
+}
+
+// ArgsUsage returns the positionals of the command as shown in usage lines.
+func (c *Command) ArgsUsage() string {
+	args := []string{}
+	for _, arg := range c.Args {
+		args = append(args, arg.String())
+	}
+	return strings.Join(args, " ")
+}
+
 func (c *Command) HelpCmd(name string) string {
 	if c.DisableHelp {
 		return ""
@@ -78,6 +367,9 @@ func (c *Command) HelpCmd(name string) string {
 	case len(c.Command) > 0 && len(c.Flags) > 0:
 		usage = fmt.Sprintf("Usage:\n  %s [command] [flags]", c.name)
 	}
+	if args := c.ArgsUsage(); len(usage) > 0 && len(args) > 0 {
+		usage += " " + args
+	}
 
 	example := ""
 	if len(c.Example) > 0 {
@@ -85,23 +377,57 @@ func (c *Command) HelpCmd(name string) string {
 	}
 
 	cmds := []string{}
-	for cmdName, cmd := range c.Command {
-		cmds = append(cmds, fmt.Sprintf("  %s\t%s", cmdName, cmd.Short))
+	for _, cmdName := range SortedKeys(c.Command) {
+		cmds = append(cmds, fmt.Sprintf("  %s\t%s", cmdName, c.Command[cmdName].Short))
 	}
 	availableCommands := ""
 	if len(cmds) > 0 {
 		availableCommands = fmt.Sprintf("Available Commands:\n%s\n", strings.Join(cmds, "\n"))
 	}
 
+	args := []string{}
+	for _, arg := range c.Args {
+		args = append(args, fmt.Sprintf("  %s\t%s", arg.String(), arg.Usage))
+	}
+	availableArgs := ""
+	if len(args) > 0 {
+		availableArgs = fmt.Sprintf("Arguments:\n%s\n", strings.Join(args, "\n"))
+	}
+
 	flags := []string{}
 	for _, flag := range c.Flags {
-		flags = append(flags, fmt.Sprintf("  %s\t%s", strings.Join(flag.Alias, ", "), flag.Usage))
+		alias := strings.Join(flag.Alias, ", ")
+		usage := flag.Usage
+		switch flag.Type {
+		case "bool":
+		case "enum":
+			alias += " " + flag.Type
+			usage = strings.TrimSpace(fmt.Sprintf("%s (%s)", usage, flag.Expected()))
+		case "list":
+			alias += " string"
+			usage = strings.TrimSpace(usage + " (can be repeated)")
+		default:
+			alias += " " + flag.Type
+		}
+		if defaults := flag.Defaults(); len(defaults) > 0 {
+			usage = strings.TrimSpace(fmt.Sprintf("%s (default %q)", usage, strings.Join(defaults, ",")))
+		}
+		if len(flag.Env) > 0 {
+			usage = strings.TrimSpace(fmt.Sprintf("%s (env %s)", usage, flag.Env))
+		}
+		if flag.Required {
+			usage = strings.TrimSpace(usage + " (required)")
+		}
+		flags = append(flags, fmt.Sprintf("  %s\t%s", alias, usage))
 	}
 
 	availableflags := ""
 	if len(flags) > 0 {
 		availableflags = fmt.Sprintf("Flags:\n%s\n", strings.Join(flags, "\n"))
 	}
+	if len(availableArgs) > 0 {
+		availableflags = availableArgs + "\n" + availableflags
+	}
 	return strings.TrimSpace(fmt.Sprintf(`%s
 
 %s
@@ -111,12 +437,47 @@ func (c *Command) HelpCmd(name string) string {
 }
 
 type Aliax struct {
-	Executable string              `yaml:"executable"`
-	RunPath    string              `yaml:"runPath"`
-	Variable   map[string]string   `yaml:"variable"`
-	Extend     map[string]*Command `yaml:"extend"`
-	Command    map[string]*Command `yaml:"command"`
-	Script     map[string]Script   `yaml:"script"`
+	// Version is the schema version of the file, see CurrentVersion.
+	Version    int    `yaml:"version"`
+	Executable string `yaml:"executable"`
+	RunPath    string `yaml:"runPath"`
+	// RunFromRoot makes the generated scripts change into the workspace
+	// root first, so that commands behave the same from any subdirectory.
+	RunFromRoot bool              `yaml:"runFromRoot"`
+	Include     []string          `yaml:"include"`
+	Variable    map[string]string `yaml:"variable"`
+	// Env sets environment variables for every script and generated command,
+	// values are templates rendered with the variables.
+	Env map[string]string `yaml:"env"`
+	// Dotenv lists the dotenv files loaded before Env, relative to the workspace root.
+	Dotenv  []string            `yaml:"dotenv"`
+	Extend  map[string]*Command `yaml:"extend"`
+	Command map[string]*Command `yaml:"command"`
+	Script  map[string]Script   `yaml:"script"`
+
+	// files lists the configuration files merged into this one, in load order.
+	files []string `yaml:"-"`
+	// sources maps "section.name" to the file declaring the entry.
+	sources map[string]string `yaml:"-"`
+	// outdated lists the merged files written for an older schema version.
+	outdated []string `yaml:"-"`
+}
+
+// Files returns the configuration files merged by Load, starting with the root file.
+func (a *Aliax) Files() []string {
+	return a.files
+}
+
+// Outdated returns the files merged by Load which were written for an
+// older schema version and should be upgraded with aliax migrate.
+func (a *Aliax) Outdated() []string {
+	return a.outdated
+}
+
+// Source returns the file declaring the named entry of a section
+// (command, env, extend, script or variable).
+func (a *Aliax) Source(section, name string) string {
+	return a.sources[section+"."+name]
 }
 
 // TODO
@@ -126,33 +487,165 @@ func (c *Commands) LoadBinPath() {
 
 }
 
-const work = "aliax.work"
+const (
+	work = "aliax.work"
+	yml  = "aliax.yaml"
+)
 
-var target = ""
+var (
+	target   = ""
+	root     = ""
+	workFile = ""
+)
 
+// SetConfig overrides the discovered configuration file,
+// the workspace root becomes the directory containing it.
+func SetConfig(name string) {
+	target = name
+	root = filepath.Dir(name)
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+}
+
+// Name returns the configuration file of the workspace. Like git, it walks up
+// from the current directory to the nearest directory containing aliax.work
+// or an aliax configuration (aliax.yaml, aliax.yml, aliax.json or aliax.toml),
+// the active configuration of aliax.work is preferred.
+// When nothing is found, aliax.yaml in the current directory is returned.
 func Name() string {
 	if len(target) > 0 {
 		return target
 	}
-	if ok, _ := aos.Exist(work); ok {
-		data, err := aos.ReadFile(work)
-		if err == nil {
-			target = string(data)
-		} else {
-			target = "aliax.yaml"
+	wd, err := os.Getwd()
+	if err != nil {
+		target, root = yml, "."
+		return target
+	}
+	target, root = yml, wd
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		if ok, _ := aos.Exist(filepath.Join(dir, work)); ok {
+			name := yml
+			if w, err := ReadWork(filepath.Join(dir, work)); err == nil {
+				name = w.Selected()
+			}
+			workFile = filepath.Join(dir, work)
+			target, root = relative(wd, filepath.Join(dir, name)), dir
+			break
+		}
+		if name := Lookup(dir, "aliax"); len(name) > 0 {
+			target, root = relative(wd, name), dir
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break
 		}
-	} else {
-		target = "aliax.yaml"
 	}
 	return target
 }
 
+// Root returns the workspace root, the directory the configuration was found in.
+func Root() string {
+	Name()
+	return root
+}
+
+// WorkFile returns the aliax.work file found while discovering the
+// configuration, or an empty string when the workspace has none.
+func WorkFile() string {
+	Name()
+	return workFile
+}
+
+func relative(base, path string) string {
+	if rel, err := filepath.Rel(base, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// Script is an entry of the script section, either a command line or an
+// object holding match cases like a command.
 type Script struct {
 	Cmd *Command
 	Run *string
+	// Deps lists the scripts to run before this one, it's only
+	// available on script objects.
+	Deps []string
+	// Parallel lists the scripts to run concurrently before this one,
+	// after its dependencies.
+	Parallel []string
+	// Sources and Generates list the glob patterns of the files read and
+	// written by the script, relative to the workspace root. A script
+	// declaring them is skipped while none of the files changed since
+	// its last successful run.
+	Sources   []string
+	Generates []string
+	// Dir is the directory the script runs in, relative to the workspace
+	// root, the dir of a match case takes precedence.
+	Dir string
+	// Watch lists the glob patterns of the files watched by aliax watch,
+	// the sources are watched when it's empty. Ignore lists patterns in
+	// the .gitignore syntax of files which never trigger a run.
+	Watch  []string
+	Ignore []string
+	// Timeout bounds every attempt to run the script, the processes it
+	// started are killed when it expires. A failed attempt is retried up
+	// to Retries times, after RetryDelay which doubles on every retry.
+	Timeout    string
+	Retries    int
+	RetryDelay string
+	// When is the condition the script runs under, the script and
+	// its dependencies are skipped when it doesn't hold.
+	When string
+
+	pos Pos
+}
+
+// scriptObject is the object form of a script,
+// a command along with the options specific to scripts.
+type scriptObject struct {
+	// Command isn't embedded, it would promote Command.UnmarshalYAML.
+	Command   Command  `yaml:",inline"`
+	Deps      []string `yaml:"deps,omitempty"`
+	Parallel  []string `yaml:"parallel,omitempty"`
+	Sources   []string `yaml:"sources,omitempty"`
+	Generates []string `yaml:"generates,omitempty"`
+	Dir       string   `yaml:"dir,omitempty"`
+	Watch     []string `yaml:"watch,omitempty"`
+	Ignore    []string `yaml:"ignore,omitempty"`
+	// Timeout and RetryDelay are durations such as 30s or 1m30s.
+	Timeout    string `yaml:"timeout,omitempty" schema:"def=Duration"`
+	Retries    int    `yaml:"retries,omitempty"`
+	RetryDelay string `yaml:"retry_delay,omitempty" schema:"def=Duration"`
+	When       string `yaml:"when,omitempty"`
+}
+
+func (sc Script) MarshalYAML() (any, error) {
+	if sc.Run != nil {
+		return *sc.Run, nil
+	}
+	obj := scriptObject{
+		Deps:       sc.Deps,
+		Parallel:   sc.Parallel,
+		Sources:    sc.Sources,
+		Generates:  sc.Generates,
+		Dir:        sc.Dir,
+		Watch:      sc.Watch,
+		Ignore:     sc.Ignore,
+		Timeout:    sc.Timeout,
+		Retries:    sc.Retries,
+		RetryDelay: sc.RetryDelay,
+		When:       sc.When,
+	}
+	if sc.Cmd != nil {
+		obj.Command = *sc.Cmd
+	}
+	return obj, nil
 }
 
 func (sc *Script) UnmarshalYAML(value *yaml.Node) error {
+	sc.pos = posOf(value)
 	if value.Kind == yaml.ScalarNode {
 		var run string
 		if err := value.Decode(&run); err != nil {
@@ -162,10 +655,22 @@ func (sc *Script) UnmarshalYAML(value *yaml.Node) error {
 		return nil
 	}
 
-	var cmd Command
-	if err := value.Decode(&cmd); err != nil {
+	var obj scriptObject
+	if err := value.Decode(&obj); err != nil {
 		return err
 	}
-	sc.Cmd = &cmd
+	obj.Command.pos = posOf(value)
+	sc.Cmd = &obj.Command
+	sc.Deps = obj.Deps
+	sc.Parallel = obj.Parallel
+	sc.Sources = obj.Sources
+	sc.Generates = obj.Generates
+	sc.Dir = obj.Dir
+	sc.Watch = obj.Watch
+	sc.Ignore = obj.Ignore
+	sc.Timeout = obj.Timeout
+	sc.Retries = obj.Retries
+	sc.RetryDelay = obj.RetryDelay
+	sc.When = obj.When
 	return nil
 }
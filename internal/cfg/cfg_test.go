@@ -0,0 +1,81 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameDiscovery(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"aliax.yaml":        "script:\n  build: go build\n",
+		"cli/cmd/.keep":     "",
+		"nested/aliax.work": "aliax.dev.yaml",
+	})
+	dir, err := filepath.EvalSymlinks(dir)
+	assert.NoError(t, err)
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	defer os.Chdir(wd)
+	defer func() { target, root = "", "" }()
+
+	assert.NoError(t, os.Chdir(filepath.Join(dir, "cli", "cmd")))
+	target, root = "", ""
+	assert.Equal(t, filepath.Join("..", "..", "aliax.yaml"), Name())
+	assert.Equal(t, dir, Root())
+
+	assert.NoError(t, os.Chdir(filepath.Join(dir, "nested")))
+	target, root = "", ""
+	assert.Equal(t, "aliax.dev.yaml", Name())
+	assert.Equal(t, filepath.Join(dir, "nested"), Root())
+
+	SetConfig(filepath.Join(dir, "aliax.yaml"))
+	assert.Equal(t, filepath.Join(dir, "aliax.yaml"), Name())
+	assert.Equal(t, dir, Root())
+}
+
+func TestFlagCheckValue(t *testing.T) {
+	for _, tc := range []struct {
+		flag  Flag
+		value string
+		ok    bool
+	}{
+		{Flag{Type: "int"}, "-42", true},
+		{Flag{Type: "int"}, "4.2", false},
+		{Flag{Type: "float"}, "4.2e3", true},
+		{Flag{Type: "float"}, ".5", true},
+		{Flag{Type: "float"}, "x", false},
+		{Flag{Type: "duration"}, "1h30m", true},
+		{Flag{Type: "duration"}, "90", false},
+		{Flag{Type: "enum", Choices: []string{"dev", "prod"}}, "prod", true},
+		{Flag{Type: "enum", Choices: []string{"dev", "prod"}}, "production", false},
+		{Flag{Type: "string"}, "anything", true},
+	} {
+		err := tc.flag.CheckValue(tc.value)
+		assert.Equal(t, tc.ok, err == nil, "%s %q", tc.flag.Type, tc.value)
+	}
+}
+
+func TestCommandArgsUsage(t *testing.T) {
+	cmd := &Command{
+		Flags: []Flag{{Name: "force", Alias: []string{"-f"}, Type: "bool"}},
+		Args: []Arg{
+			{Name: "src", Required: true, Usage: "file to copy"},
+			{Name: "dst", Usage: "destination"},
+			{Name: "rest", Variadic: true},
+		},
+	}
+	cmd.SetName("cp")
+	assert.Equal(t, "<src> [dst] [rest...]", cmd.ArgsUsage())
+	min, max := cmd.ArgsRange()
+	assert.Equal(t, 1, min)
+	assert.Equal(t, -1, max)
+	assert.Contains(t, cmd.HelpCmd("cp"), "Usage:\n  cp [flags] <src> [dst] [rest...]")
+	assert.Contains(t, cmd.HelpCmd("cp"), "Arguments:\n  <src>\tfile to copy\n  [dst]\tdestination\n")
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pos describes a position inside a configuration file.
+type Pos struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Pos) String() string {
+	switch {
+	case p.Line == 0:
+		return p.File
+	case len(p.File) == 0:
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	case p.Column == 0:
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+func posOf(node *yaml.Node) Pos {
+	return Pos{Line: node.Line, Column: node.Column}
+}
+
+// Error is a problem found in a configuration file.
+type Error struct {
+	Pos
+	// Path is the dotted key path of the offending value, e.g. command.git.flags[0].type.
+	Path string
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	msg := e.Msg
+	if len(e.Path) > 0 {
+		msg = fmt.Sprintf("%s: %s", e.Path, e.Msg)
+	}
+	if pos := e.Pos.String(); len(pos) > 0 {
+		return fmt.Sprintf("%s: %s", pos, msg)
+	}
+	return msg
+}
+
+var (
+	typeErrorLine  = regexp.MustCompile(`^line (\d+): (.*)$`)
+	typeErrorValue = regexp.MustCompile("`([^`]*)`|field (\\S+) not found")
+)
+
+// fromTypeError converts the messages of a *yaml.TypeError into positioned errors.
+func fromTypeError(file string, err *yaml.TypeError, doc *yaml.Node) []*Error {
+	errs := []*Error{}
+	for _, msg := range err.Errors {
+		e := &Error{Pos: Pos{File: file}, Msg: msg}
+		if matched := typeErrorLine.FindStringSubmatch(msg); matched != nil {
+			e.Line, _ = strconv.Atoi(matched[1])
+			e.Msg = matched[2]
+			value := ""
+			if v := typeErrorValue.FindStringSubmatch(e.Msg); v != nil {
+				value = v[1] + v[2]
+			}
+			if node := findNode(doc, e.Line, value); node != nil {
+				e.Column = node.Column
+			}
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// findNode returns the node on the line holding value, or the first one on it.
+func findNode(node *yaml.Node, line int, value string) *yaml.Node {
+	var first *yaml.Node
+	var walk func(n *yaml.Node) *yaml.Node
+	walk = func(n *yaml.Node) *yaml.Node {
+		if n.Line == line && n.Kind == yaml.ScalarNode && n.Value == value {
+			return n
+		}
+		if n.Line == line && first == nil && n.Kind != yaml.DocumentNode {
+			first = n
+		}
+		for i, c := range n.Content {
+			if n.Kind == yaml.MappingNode && i%2 == 0 && c.Line == line && first == nil && n.Content[i+1].Line == line {
+				first = n.Content[i+1]
+			}
+			if found := walk(c); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	if node == nil {
+		return nil
+	}
+	if found := walk(node); found != nil {
+		return found
+	}
+	return first
+}
+
+func joinPath(path string, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	if strings.HasPrefix(key, "[") {
+		return path + key
+	}
+	return path + "." + key
+}
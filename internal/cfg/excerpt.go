@@ -0,0 +1,75 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"aliax/internal/style"
+)
+
+// excerptContext is the number of lines shown before the offending one.
+const excerptContext = 2
+
+// Excerpt renders the lines of the file leading to the position of the error,
+// with carets under the offending value:
+//
+//	6 |     flags:
+//	7 |       - name: message
+//	8 |         type: strnig
+//	  |               ^^^^^^
+//
+// It returns an empty string when the position has no line or the file can't be read.
+func (e *Error) Excerpt() string {
+	if len(e.File) == 0 || e.Line == 0 {
+		return ""
+	}
+	data, err := os.ReadFile(e.File)
+	if err != nil {
+		return ""
+	}
+	return excerpt(strings.Split(string(data), "\n"), e.Line, e.Column)
+}
+
+func excerpt(lines []string, line, column int) string {
+	if line > len(lines) {
+		return ""
+	}
+	width := len(fmt.Sprint(line))
+	gutter := func(n string) string {
+		return style.Bold(fmt.Sprintf(" %*s |", width, n))
+	}
+
+	buf := &strings.Builder{}
+	for n := max(line-excerptContext, 1); n <= line; n++ {
+		fmt.Fprintf(buf, "%s %s\n", gutter(fmt.Sprint(n)), strings.TrimRight(lines[n-1], "\r"))
+	}
+	if column > 0 {
+		text := []rune(lines[line-1])
+		if column > len(text)+1 {
+			column = len(text) + 1
+		}
+		// keep the tabs of the line so that the carets stay aligned
+		indent := []rune{}
+		for _, r := range text[:column-1] {
+			if r == '\t' {
+				indent = append(indent, '\t')
+			} else {
+				indent = append(indent, ' ')
+			}
+		}
+		size := 0
+		for _, r := range text[column-1:] {
+			if unicode.IsSpace(r) || (size > 0 && r == ':') {
+				break
+			}
+			size++
+		}
+		fmt.Fprintf(buf, "%s %s%s\n", gutter(""), string(indent), style.Removed(strings.Repeat("^", max(size, 1))))
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"aliax/internal/aos"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Extensions lists the supported configuration formats in lookup order.
+// JSON is a subset of YAML and is parsed as such, so that problems keep
+// their positions. TOML is converted into a YAML node before decoding.
+var Extensions = []string{".yaml", ".yml", ".json", ".toml"}
+
+// Lookup returns the first existing file named base with a supported
+// extension in dir, or an empty string when there is none.
+func Lookup(dir, base string) string {
+	for _, ext := range Extensions {
+		name := filepath.Join(dir, base+ext)
+		if ok, _ := aos.Exist(name); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+func isTOML(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".toml")
+}
+
+func isJSON(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".json")
+}
+
+// parse parses the configuration file into a YAML document node, whatever its format.
+func parse(name string, data []byte) (*yaml.Node, error) {
+	doc := &yaml.Node{}
+	if !isTOML(name) {
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return nil, err
+		}
+		return doc, nil
+	}
+
+	var v map[string]any
+	md, err := toml.Decode(string(data), &v)
+	if err != nil {
+		return nil, err
+	}
+	order := map[string]int{}
+	for i, key := range md.Keys() {
+		order[strings.Join(key, "\x00")] = i
+	}
+	doc.Kind = yaml.DocumentNode
+	doc.Content = []*yaml.Node{tomlNode("", v, order)}
+	return doc, nil
+}
+
+// tomlNode converts a decoded TOML value into a YAML node. The keys of tables
+// follow their order in the file, order maps the key paths to their rank.
+func tomlNode(path string, v any, order map[string]int) *yaml.Node {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		rank := func(k string) int {
+			if i, ok := order[joinKey(path, k)]; ok {
+				return i
+			}
+			return len(order)
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			if rank(keys[i]) != rank(keys[j]) {
+				return rank(keys[i]) < rank(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range keys {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k},
+				tomlNode(joinKey(path, k), v[k], order))
+		}
+		return node
+	case []map[string]any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, e := range v {
+			node.Content = append(node.Content, tomlNode(path, e, order))
+		}
+		return node
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, e := range v {
+			node.Content = append(node.Content, tomlNode(path, e, order))
+		}
+		return node
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case int64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(v, 10)}
+	case float64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(v, 'g', -1, 64)}
+	case time.Time:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.Format(time.RFC3339)}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(v)}
+}
+
+func joinKey(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "\x00" + key
+}
+
+// Marshal encodes v in the format of the named file.
+func Marshal(name string, v any) ([]byte, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+	return encode(name, &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{node}}, 2)
+}
+
+// encode writes the YAML document node in the format of the named file,
+// indenting the nested values of YAML and JSON by indent spaces.
+func encode(name string, doc *yaml.Node, indent int) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	switch {
+	case isJSON(name):
+		if err := writeJSON(buf, doc); err != nil {
+			return nil, err
+		}
+		out := &bytes.Buffer{}
+		if err := json.Indent(out, buf.Bytes(), "", strings.Repeat(" ", indent)); err != nil {
+			return nil, err
+		}
+		out.WriteString("\n")
+		return out.Bytes(), nil
+	case isTOML(name):
+		var v map[string]any
+		if err := doc.Decode(&v); err != nil {
+			return nil, err
+		}
+		if err := toml.NewEncoder(buf).Encode(dropNull(v)); err != nil {
+			return nil, err
+		}
+	default:
+		encoder := yaml.NewEncoder(buf)
+		encoder.SetIndent(indent)
+		if err := encoder.Encode(doc); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes the node as compact JSON, keeping the order of the keys.
+func writeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return writeJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteString("{")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			key, _ := json.Marshal(node.Content[i].Value)
+			buf.Write(key)
+			buf.WriteString(":")
+			if err := writeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("}")
+	case yaml.SequenceNode:
+		buf.WriteString("[")
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	default:
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// dropNull removes the null values TOML can't represent.
+func dropNull(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			if e == nil {
+				delete(v, k)
+				continue
+			}
+			v[k] = dropNull(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = dropNull(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,332 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"aliax/internal/aos"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Errors is a list of configuration problems reported together.
+type Errors []*Error
+
+func (errs Errors) Error() string {
+	msgs := []string{}
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Load reads the configuration file and merges the files listed by its
+// include section into it. Includes are resolved relative to the including
+// file and may use glob patterns, matches of a pattern are merged in lexical
+// order. Declaring the same command, env, extend, script or variable twice is an error.
+func Load(name string) (*Aliax, error) {
+	root, err := decodeFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &loader{
+		root:    root,
+		visited: map[string]struct{}{},
+	}
+	l.visit(name)
+	l.include(name, root.Include)
+	root.Include = nil
+	if len(l.errs) > 0 {
+		return nil, l.errs
+	}
+	return root, nil
+}
+
+func decodeFile(name string) (*Aliax, error) {
+	data, err := aos.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var file Aliax
+	doc, err := parse(name, data)
+	if err == nil && len(doc.Content) > 0 {
+		err = doc.Decode(&file)
+	}
+	var typeErr *yaml.TypeError
+	switch {
+	case errors.As(err, &typeErr):
+		return nil, Errors(fromTypeError(name, typeErr, doc))
+	case err != nil:
+		e := syntaxError(err)
+		e.File = name
+		return nil, Errors{e}
+	}
+	if file.Version > CurrentVersion {
+		return nil, Errors{{
+			Pos:  Pos{File: name},
+			Path: "version",
+			Msg:  fmt.Sprintf("version %d is newer than the supported version %d, please upgrade aliax", file.Version, CurrentVersion),
+		}}
+	}
+	if file.Version < CurrentVersion {
+		file.outdated = []string{name}
+	}
+	file.setFile(name)
+	file.files = []string{name}
+	file.sources = map[string]string{}
+	file.track(name, &file)
+	return &file, nil
+}
+
+type loader struct {
+	root    *Aliax
+	visited map[string]struct{}
+	errs    Errors
+}
+
+// visit marks the file as loaded and reports whether it was seen before.
+func (l *loader) visit(name string) bool {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		abs = name
+	}
+	if _, ok := l.visited[abs]; ok {
+		return true
+	}
+	l.visited[abs] = struct{}{}
+	return false
+}
+
+func (l *loader) include(from string, patterns []string) {
+	dir := filepath.Dir(from)
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			l.errs = append(l.errs, &Error{Pos: Pos{File: from}, Path: "include", Msg: err.Error()})
+			continue
+		}
+		if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			l.errs = append(l.errs, &Error{Pos: Pos{File: from}, Path: "include", Msg: fmt.Sprintf("%s does not exist", pattern)})
+			continue
+		}
+		sort.Strings(matches)
+		for _, name := range matches {
+			if l.visit(name) {
+				continue
+			}
+			file, err := decodeFile(name)
+			if err != nil {
+				var errs Errors
+				if errors.As(err, &errs) {
+					l.errs = append(l.errs, errs...)
+				} else {
+					l.errs = append(l.errs, &Error{Pos: Pos{File: name}, Msg: err.Error()})
+				}
+				continue
+			}
+			if len(file.Executable) > 0 || len(file.RunPath) > 0 || file.RunFromRoot {
+				l.errs = append(l.errs, &Error{
+					Pos: Pos{File: name},
+					Msg: "executable, runPath and runFromRoot can only be set in the root configuration",
+				})
+			}
+			l.merge(file)
+			l.include(name, file.Include)
+		}
+	}
+}
+
+// merge adds the entries of file to the root configuration.
+func (l *loader) merge(file *Aliax) {
+	l.root.files = append(l.root.files, file.files...)
+	l.root.outdated = append(l.root.outdated, file.outdated...)
+
+	for _, k := range SortedKeys(file.Variable) {
+		if l.conflict("variable", k, file.Source("variable", k), Pos{File: file.Source("variable", k)}) {
+			continue
+		}
+		if l.root.Variable == nil {
+			l.root.Variable = map[string]string{}
+		}
+		l.root.Variable[k] = file.Variable[k]
+	}
+	for _, k := range SortedKeys(file.Env) {
+		if l.conflict("env", k, file.Source("env", k), Pos{File: file.Source("env", k)}) {
+			continue
+		}
+		if l.root.Env == nil {
+			l.root.Env = map[string]string{}
+		}
+		l.root.Env[k] = file.Env[k]
+	}
+	l.root.Dotenv = append(l.root.Dotenv, file.Dotenv...)
+	for _, k := range SortedKeys(file.Extend) {
+		if l.conflict("extend", k, file.Source("extend", k), file.Extend[k].position(file.Source("extend", k))) {
+			continue
+		}
+		if l.root.Extend == nil {
+			l.root.Extend = map[string]*Command{}
+		}
+		l.root.Extend[k] = file.Extend[k]
+	}
+	for _, k := range SortedKeys(file.Command) {
+		if l.conflict("command", k, file.Source("command", k), file.Command[k].position(file.Source("command", k))) {
+			continue
+		}
+		if l.root.Command == nil {
+			l.root.Command = map[string]*Command{}
+		}
+		l.root.Command[k] = file.Command[k]
+	}
+	for _, k := range SortedKeys(file.Script) {
+		if l.conflict("script", k, file.Source("script", k), file.Script[k].pos) {
+			continue
+		}
+		if l.root.Script == nil {
+			l.root.Script = map[string]Script{}
+		}
+		l.root.Script[k] = file.Script[k]
+	}
+}
+
+// conflict records an error when the entry is already declared by another file.
+// Commands and extends share a namespace since both generate a run-script.
+func (l *loader) conflict(section, key, name string, pos Pos) bool {
+	prevSection := section
+	prev, ok := l.root.sources[section+"."+key]
+	if !ok && (section == "command" || section == "extend") {
+		prevSection = map[string]string{"command": "extend", "extend": "command"}[section]
+		prev, ok = l.root.sources[prevSection+"."+key]
+	}
+	if !ok {
+		l.root.sources[section+"."+key] = name
+		return false
+	}
+	msg := fmt.Sprintf("%s %q is declared in both %s and %s", section, key, prev, name)
+	if prevSection != section {
+		msg = fmt.Sprintf("%s %q in %s conflicts with %s %q in %s", section, key, name, prevSection, key, prev)
+	}
+	l.errs = append(l.errs, &Error{Pos: pos, Path: joinPath(section, key), Msg: msg})
+	return true
+}
+
+// track records the file as the source of the entries it declares.
+func (a *Aliax) track(name string, file *Aliax) {
+	for k := range file.Variable {
+		a.sources["variable."+k] = name
+	}
+	for k := range file.Env {
+		a.sources["env."+k] = name
+	}
+	for k := range file.Extend {
+		a.sources["extend."+k] = name
+	}
+	for k := range file.Command {
+		a.sources["command."+k] = name
+	}
+	for k := range file.Script {
+		a.sources["script."+k] = name
+	}
+}
+
+// setFile stamps the file name on the positions of every decoded node.
+func (a *Aliax) setFile(name string) {
+	for _, cmd := range a.Extend {
+		cmd.setFile(name)
+	}
+	for _, cmd := range a.Command {
+		cmd.setFile(name)
+	}
+	for k, sc := range a.Script {
+		sc.pos.File = name
+		sc.Cmd.setFile(name)
+		a.Script[k] = sc
+	}
+}
+
+// position returns where the command is declared, commands left empty
+// in the file only know their file.
+func (c *Command) position(file string) Pos {
+	if c == nil {
+		return Pos{File: file}
+	}
+	return c.pos
+}
+
+func (c *Command) setFile(name string) {
+	if c == nil {
+		return
+	}
+	c.pos.File = name
+	for i := range c.Flags {
+		c.Flags[i].pos.File = name
+	}
+	for i := range c.Args {
+		c.Args[i].pos.File = name
+	}
+	for i := range c.Match {
+		c.Match[i].pos.File = name
+	}
+	for _, sub := range c.Command {
+		sub.setFile(name)
+	}
+}
+
+// LoadWork loads every configuration used by the work file and merges them,
+// the same way included files are merged. The active configuration comes first
+// and provides executable and runPath, the other ones are merged in use order.
+// Entries declared by more than one configuration are reported with both files.
+func LoadWork(name string, w *Work) (*Aliax, error) {
+	dir := filepath.Dir(name)
+	paths := []string{}
+	if len(w.Active) > 0 {
+		paths = append(paths, w.Active)
+	}
+	for _, use := range w.Use {
+		if filepath.Clean(use) != filepath.Clean(w.Active) {
+			paths = append(paths, use)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%s doesn't use any configuration", name)
+	}
+
+	var l *loader
+	errs := Errors{}
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		file, err := Load(path)
+		if err != nil {
+			var fileErrs Errors
+			if errors.As(err, &fileErrs) {
+				errs = append(errs, fileErrs...)
+				continue
+			}
+			return nil, err
+		}
+		if l == nil {
+			l = &loader{root: file, visited: map[string]struct{}{}}
+			continue
+		}
+		l.merge(file)
+	}
+	if l != nil {
+		errs = append(errs, l.errs...)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return l.root, nil
+}
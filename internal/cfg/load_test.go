@@ -0,0 +1,103 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestLoadInclude(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"aliax.yaml":     "include: [aliax.d/*.yaml]\nscript:\n  build: go build\n",
+		"aliax.d/b.yaml": "script:\n  test: go test\nvariable:\n  Output: dist\n",
+		"aliax.d/a.yaml": "command:\n  hello:\n    match:\n      - run: echo hello\n",
+	})
+
+	file, err := Load(filepath.Join(dir, "aliax.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "aliax.yaml"),
+		filepath.Join(dir, "aliax.d", "a.yaml"),
+		filepath.Join(dir, "aliax.d", "b.yaml"),
+	}, file.Files())
+	assert.Len(t, file.Script, 2)
+	assert.Contains(t, file.Command, "hello")
+	assert.Equal(t, "dist", file.Variable["Output"])
+	assert.Equal(t, filepath.Join(dir, "aliax.d", "b.yaml"), file.Source("script", "test"))
+	assert.Equal(t, filepath.Join(dir, "aliax.yaml"), file.Source("script", "build"))
+}
+
+func TestLoadIncludeConflict(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"aliax.yaml": "include: [a.yaml, b.yaml]\n",
+		"a.yaml":     "command:\n  hello:\n    match:\n      - run: echo a\n",
+		"b.yaml":     "command:\n  hello:\n    match:\n      - run: echo b\n",
+	})
+
+	_, err := Load(filepath.Join(dir, "aliax.yaml"))
+	assert.EqualError(t, err, filepath.Join(dir, "b.yaml")+`:3:5: command.hello: command "hello" is declared in both `+
+		filepath.Join(dir, "a.yaml")+" and "+filepath.Join(dir, "b.yaml"))
+}
+
+func TestLoadFormats(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"aliax.toml": `version = 1
+include = ["scripts.json"]
+
+[script]
+build = "go build"
+
+[[script.test.match]]
+pattern = "_"
+run = "go test ./..."
+`,
+		"scripts.json": `{"version": 1, "script": {"lint": "go vet ./..."}}`,
+	})
+
+	file, err := Load(filepath.Join(dir, "aliax.toml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "go build", *file.Script["build"].Run)
+	assert.Equal(t, "go vet ./...", *file.Script["lint"].Run)
+	if assert.NotNil(t, file.Script["test"].Cmd) {
+		assert.Equal(t, "go test ./...", file.Script["test"].Cmd.Match[0].Run)
+	}
+
+	data, err := Marshal("aliax.json", &Aliax{Script: map[string]Script{"build": file.Script["build"]}})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"build": "go build"`)
+}
+
+func TestLoadErrorPosition(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"aliax.yaml": "command:\n  x:\n    match:\n      - run: echo\n        platform: [bash]\n",
+	})
+
+	_, err := Load(filepath.Join(dir, "aliax.yaml"))
+	var errs Errors
+	if assert.ErrorAs(t, err, &errs) && assert.Len(t, errs, 1) {
+		assert.Equal(t, 5, errs[0].Line)
+		assert.Equal(t, 19, errs[0].Column)
+		assert.Equal(t, strings.Join([]string{
+			" 3 |     match:",
+			" 4 |       - run: echo",
+			" 5 |         platform: [bash]",
+			"   |                   ^^^^^^",
+		}, "\n"), errs[0].Excerpt())
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Rank returns the key the match cases are tried by, in decreasing order.
+func (c *Command) Rank(m Case) [2]int {
+	rank := [2]int{m.Priority, 0}
+	if c.Order != "declared" {
+		rank[1] = len(m.Names())
+	}
+	return rank
+}
+
+// Lint reports the match cases which may match the same arguments as a case
+// of the same rank declared before them.
+func (a *Aliax) Lint() []*Error {
+	errs := []*Error{}
+	for _, name := range SortedKeys(a.Extend) {
+		errs = append(errs, a.Extend[name].lint(joinPath("extend", name))...)
+	}
+	for _, name := range SortedKeys(a.Command) {
+		errs = append(errs, a.Command[name].lint(joinPath("command", name))...)
+	}
+	for _, name := range SortedKeys(a.Script) {
+		if sc := a.Script[name]; sc.Cmd != nil {
+			errs = append(errs, sc.Cmd.lint(joinPath("script", name))...)
+		}
+	}
+	return errs
+}
+
+func (c *Command) lint(path string) []*Error {
+	if c == nil {
+		return nil
+	}
+	errs := []*Error{}
+	types := map[string]string{}
+	for _, flag := range c.Flags {
+		types[flag.Name] = flag.Type
+	}
+	tests := make([][]Test, len(c.Match))
+	for i := range c.Match {
+		tests[i], _ = c.Match[i].Tests()
+	}
+	for j, y := range c.Match {
+		if c.Order == "declared" || len(tests[j]) == 0 {
+			continue
+		}
+		for i, x := range c.Match[:j] {
+			if len(tests[i]) == 0 || c.Rank(x) != c.Rank(y) ||
+				len(x.Platform) > 0 && len(y.Platform) > 0 && x.Platform != y.Platform {
+				continue
+			}
+			if overlap(tests[i], tests[j], types) {
+				errs = append(errs, &Error{
+					Pos:  y.pos,
+					Path: joinPath(path, fmt.Sprintf("match[%d]", j)),
+					Msg:  fmt.Sprintf("case may match the same arguments as match[%d], which wins as it's declared first", i),
+				})
+				break
+			}
+		}
+	}
+	for _, name := range SortedKeys(c.Command) {
+		errs = append(errs, c.Command[name].lint(joinPath(path, "command."+name))...)
+	}
+	return errs
+}
+
+// overlap reports whether the tests of two patterns may hold for the same values.
+func overlap(x, y []Test, types map[string]string) bool {
+	for _, a := range x {
+		for _, b := range y {
+			if a.Name != b.Name {
+				continue
+			}
+			candidates := append([]string{"", other}, a.Values...)
+			candidates = append(candidates, b.Values...)
+			if types[a.Name] == "bool" {
+				candidates = []string{"true", "false"}
+			}
+			found := false
+			for _, v := range candidates {
+				if a.holds(v, types[a.Name]) && b.holds(v, types[a.Name]) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// other stands for any value not compared by the tests.
+const other = "\x00"
+
+// holds reports whether the test holds for the value v of a flag of type typ.
+func (t Test) holds(v, typ string) bool {
+	var ok bool
+	switch t.Op {
+	case "":
+		ok = len(v) > 0 && (typ != "bool" || v == "true")
+	case "==":
+		ok = v == t.Values[0]
+	case "~":
+		if v == other {
+			return true
+		}
+		re, err := regexp.Compile(t.Values[0])
+		ok = err == nil && re.MatchString(v)
+	case "in":
+		for _, e := range t.Values {
+			ok = ok || v == e
+		}
+	}
+	return ok != t.Negate
+}
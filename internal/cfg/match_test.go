@@ -0,0 +1,89 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRank(t *testing.T) {
+	var c Command
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+match:
+  - pattern: [a, b]
+  - pattern: a
+    priority: 2
+`), &c))
+	assert.Equal(t, [2]int{0, 2}, c.Rank(c.Match[0]))
+	assert.Equal(t, [2]int{2, 1}, c.Rank(c.Match[1]))
+	c.Order = "declared"
+	assert.Equal(t, [2]int{0, 0}, c.Rank(c.Match[0]))
+	assert.Equal(t, [2]int{2, 0}, c.Rank(c.Match[1]))
+}
+
+func TestLint(t *testing.T) {
+	var file Aliax
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+command:
+  deploy:
+    flags:
+      - name: env
+        type: string
+      - name: force
+        type: bool
+      - name: dry
+        type: bool
+    match:
+      - pattern: env
+        run: deploy
+      - pattern: force
+        run: deploy --force
+      - pattern: {env: prod}
+        run: deploy prod
+        priority: 1
+      - pattern: {env: dev}
+        run: deploy dev
+        priority: 1
+      - pattern: {env: "~^pro"}
+        run: deploy pro
+        priority: 1
+      - pattern: {force: false}
+        run: deploy
+        priority: 2
+      - pattern: {force: true}
+        run: deploy
+        priority: 2
+      - pattern: dry
+        platform: bash
+        run: deploy
+        priority: 3
+      - pattern: dry
+        platform: powershell
+        run: deploy
+        priority: 3
+      - run: deploy
+    command:
+      rollback:
+        order: declared
+        flags:
+          - name: env
+            type: string
+        match:
+          - pattern: env
+            run: rollback
+          - pattern: {env: prod}
+            run: rollback prod
+`), &file))
+	msgs := []string{}
+	for _, e := range file.Lint() {
+		msgs = append(msgs, e.Error())
+	}
+	assert.Equal(t, []string{
+		`14:9: command.deploy.match[1]: case may match the same arguments as match[0], which wins as it's declared first`,
+		`22:9: command.deploy.match[4]: case may match the same arguments as match[2], which wins as it's declared first`,
+	}, msgs)
+}
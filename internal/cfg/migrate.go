@@ -0,0 +1,225 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the schema version of the configuration understood by aliax.
+// A file without a version field is at version 0.
+const CurrentVersion = 1
+
+// Migration upgrades a configuration document from the From version to the next one.
+// It works on the yaml.Node tree of the document, so that comments and the
+// order of the keys are kept when it's written back.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(root *yaml.Node) error
+}
+
+// migrations is the registry of the schema upgrades, ordered by version.
+// Changing the layout of Aliax, Command or Script means bumping
+// CurrentVersion and registering the migration rewriting older files.
+var migrations = []Migration{
+	{
+		From:        0,
+		Description: "declare the schema version",
+		Apply:       func(root *yaml.Node) error { return nil },
+	},
+}
+
+// Migrate upgrades the configuration document to CurrentVersion and returns
+// the migrations applied, none when the document is up to date.
+// When the migrations only bump the version, the version key is edited in
+// place, otherwise the document is written back in the format of the named
+// file with its indentation, which TOML files can't keep.
+func Migrate(name string, data []byte) ([]byte, []Migration, error) {
+	doc, err := parse(name, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("%s: expected object, got %s", posOf(root), kindOf(root))
+	}
+
+	version, err := versionOf(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version > CurrentVersion {
+		return nil, nil, fmt.Errorf("version %d is newer than the supported version %d, please upgrade aliax", version, CurrentVersion)
+	}
+	_, value := versionNode(root)
+	indent := indentOf(root)
+
+	applied := []Migration{}
+	changed := false
+	for _, m := range migrations {
+		if m.From < version {
+			continue
+		}
+		before, _ := yaml.Marshal(root)
+		if err := m.Apply(root); err != nil {
+			return nil, nil, fmt.Errorf("migrating from version %d: %w", m.From, err)
+		}
+		after, _ := yaml.Marshal(root)
+		changed = changed || !bytes.Equal(before, after)
+		setVersion(root, m.From+1)
+		applied = append(applied, m)
+	}
+	if len(applied) == 0 {
+		return data, nil, nil
+	}
+	if !changed {
+		return writeVersion(name, data, root, value), applied, nil
+	}
+	if isTOML(name) {
+		return nil, nil, fmt.Errorf("the migrations rewrite the document, which loses the comments of TOML files: convert it to YAML or migrate it by hand")
+	}
+
+	out, err := encode(name, doc, indent)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, applied, nil
+}
+
+// versionNode returns the key and the value of the version key of the mapping, if any.
+func versionNode(root *yaml.Node) (key, value *yaml.Node) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "version" {
+			return root.Content[i], root.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// versionOf returns the value of the version key of the mapping.
+func versionOf(root *yaml.Node) (int, error) {
+	_, value := versionNode(root)
+	if value == nil {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(value.Value)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("%s: version: expected a positive integer, got %q", posOf(value), value.Value)
+	}
+	return version, nil
+}
+
+// setVersion sets the version key of the mapping, adding it as the first key when missing.
+func setVersion(root *yaml.Node, version int) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "version" {
+			root.Content[i+1] = value
+			return
+		}
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	if len(root.Content) > 0 {
+		// keep the header comment of the file on top
+		key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
+
+// indentOf returns the indentation of the nested block mappings of root, 2 when there are none.
+func indentOf(root *yaml.Node) int {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0 && value.Content[0].Column > key.Column {
+			return value.Content[0].Column - key.Column
+		}
+	}
+	return 2
+}
+
+var tomlVersion = regexp.MustCompile(`^(\s*version\s*=\s*)[^\s#]+`)
+
+// writeVersion sets the version key of the source of the document to CurrentVersion,
+// the rest of the source is kept as is. value is the version parsed from data,
+// nil when it's missing.
+func writeVersion(name string, data []byte, root, value *yaml.Node) []byte {
+	version := strconv.Itoa(CurrentVersion)
+	lines := strings.SplitAfter(string(data), "\n")
+	if isTOML(name) {
+		// the top-level keys precede the first table
+		insert := len(lines)
+		for i, line := range lines {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "[") {
+				insert = min(insert, i)
+				break
+			}
+			if m := tomlVersion.FindStringSubmatchIndex(line); m != nil {
+				lines[i] = line[:m[3]] + version + line[m[1]:]
+				return []byte(strings.Join(lines, ""))
+			}
+			if len(trimmed) > 0 && !strings.HasPrefix(trimmed, "#") {
+				insert = min(insert, i)
+			}
+		}
+		return []byte(insertLine(lines, insert, "version = "+version))
+	}
+
+	if value != nil {
+		// the value is a number, possibly quoted
+		width := len(value.Value)
+		if value.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			width += 2
+		}
+		line := []rune(lines[value.Line-1])
+		lines[value.Line-1] = string(line[:value.Column-1]) + version + string(line[value.Column-1+width:])
+		return []byte(strings.Join(lines, ""))
+	}
+	entry := "version: " + version
+	if isJSON(name) {
+		entry = `"version": ` + version
+	}
+	if len(root.Content) == 2 {
+		// the document had no key
+		if root.Line > 0 && root.Style&yaml.FlowStyle != 0 {
+			line := []rune(lines[root.Line-1])
+			lines[root.Line-1] = string(line[:root.Column]) + entry + string(line[root.Column:])
+			return []byte(strings.Join(lines, ""))
+		}
+		return []byte(insertLine(lines, len(lines), entry))
+	}
+	// the version goes before the first key, on its own line when the key starts its line
+	first := root.Content[2]
+	line := []rune(lines[first.Line-1])
+	prefix := string(line[:first.Column-1])
+	sep := ", "
+	if len(strings.TrimSpace(prefix)) == 0 {
+		sep = "\n" + prefix
+		if root.Style&yaml.FlowStyle != 0 {
+			sep = "," + sep
+		}
+	}
+	lines[first.Line-1] = prefix + entry + sep + string(line[first.Column-1:])
+	return []byte(strings.Join(lines, ""))
+}
+
+// insertLine inserts text as the line at index i of lines.
+func insertLine(lines []string, i int, text string) string {
+	if i == len(lines) && i > 0 && len(lines[i-1]) > 0 && !strings.HasSuffix(lines[i-1], "\n") {
+		text = "\n" + text
+	}
+	lines = append(lines[:i], append([]string{text + "\n"}, lines[i:]...)...)
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrate(t *testing.T) {
+	data, applied, err := Migrate("aliax.yaml", []byte(`# project aliases
+executable: aliax
+script:
+  # build the cli
+  build: go build
+`))
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+	assert.Equal(t, `# project aliases
+version: 1
+executable: aliax
+script:
+  # build the cli
+  build: go build
+`, string(data))
+
+	again, applied, err := Migrate("aliax.yaml", data)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+	assert.Equal(t, data, again)
+
+	_, _, err = Migrate("aliax.yaml", []byte("version: 99\n"))
+	assert.Error(t, err)
+}
+
+func TestMigrateInPlace(t *testing.T) {
+	for _, tc := range []struct {
+		name, src, want string
+	}{
+		{
+			"aliax.yaml",
+			"script:\n    build:   [go, build]   # the cli\n",
+			"version: 1\nscript:\n    build:   [go, build]   # the cli\n",
+		},
+		{
+			"aliax.yaml",
+			"executable: aliax\nversion: '0'   # old\n",
+			"executable: aliax\nversion: 1   # old\n",
+		},
+		{
+			"aliax.yaml",
+			"",
+			"version: 1\n",
+		},
+		{
+			"aliax.json",
+			"{\n    \"executable\":  \"aliax\"\n}\n",
+			"{\n    \"version\": 1,\n    \"executable\":  \"aliax\"\n}\n",
+		},
+		{
+			"aliax.json",
+			"{\"executable\": \"aliax\"}",
+			"{\"version\": 1, \"executable\": \"aliax\"}",
+		},
+		{
+			"aliax.json",
+			"{}\n",
+			"{\"version\": 1}\n",
+		},
+		{
+			"aliax.toml",
+			"# project aliases\n\nexecutable = 'aliax'\n\n[script.build]\n# the cli\nrun = 'go build'\n",
+			"# project aliases\n\nversion = 1\nexecutable = 'aliax'\n\n[script.build]\n# the cli\nrun = 'go build'\n",
+		},
+		{
+			"aliax.toml",
+			"executable = 'aliax'\nversion = 0 # old\n",
+			"executable = 'aliax'\nversion = 1 # old\n",
+		},
+		{
+			"aliax.toml",
+			"# nothing yet",
+			"# nothing yet\nversion = 1\n",
+		},
+	} {
+		data, applied, err := Migrate(tc.name, []byte(tc.src))
+		if assert.NoError(t, err, tc.src) {
+			assert.Len(t, applied, 1)
+			assert.Equal(t, tc.want, string(data))
+		}
+	}
+}
+
+func TestMigrateRewrite(t *testing.T) {
+	defer func(m []Migration) { migrations = m }(migrations)
+	migrations = []Migration{{
+		From: 0,
+		Apply: func(root *yaml.Node) error {
+			root.Content[1].Content[1].Value = "go build ./cli"
+			return nil
+		},
+	}}
+
+	data, _, err := Migrate("aliax.yaml", []byte("script:\n    # build the cli\n    build: go build\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "version: 1\nscript:\n    # build the cli\n    build: go build ./cli\n", string(data))
+
+	_, _, err = Migrate("aliax.toml", []byte("[script]\nbuild = 'go build'\n"))
+	assert.Error(t, err)
+}
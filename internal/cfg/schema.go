@@ -0,0 +1,311 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is the subset of JSON Schema (draft 2020-12) needed to describe
+// an aliax configuration file.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// falseSchema rejects every value, it's used to forbid unknown keys.
+var falseSchema = &Schema{Not: &Schema{}}
+
+// predefined holds the definitions which can't be derived from the Go types,
+// either because the field is untyped or because the type has a custom decoder.
+var predefined = map[string]*Schema{
+	"Pattern": {
+		Description: `flag names selecting the case, "_" or empty for the default case, or flag names mapped to the value they must have`,
+		OneOf: []*Schema{
+			{Type: "string"},
+			{Type: "array", Items: &Schema{Type: "string"}},
+			{Type: "object"},
+		},
+	},
+	"Duration": {
+		Description: "a duration such as 30s or 1m30s",
+		Type:        "string",
+	},
+	"Script": {
+		Description: "a command line, or a command object with match cases",
+		OneOf: []*Schema{
+			{Type: "string"},
+			{Ref: "#/$defs/ScriptObject"},
+		},
+	},
+}
+
+// GenerateSchema returns the JSON Schema of the aliax configuration file.
+func GenerateSchema() *Schema {
+	g := &schemaGenerator{defs: map[string]*Schema{}}
+	root := g.object(reflect.TypeOf(Aliax{}))
+	g.defs["ScriptObject"] = g.object(reflect.TypeOf(scriptObject{}))
+	root.Schema = "https://json-schema.org/draft/2020-12/schema"
+	root.Title = "aliax configuration"
+	root.Defs = g.defs
+	return root
+}
+
+type schemaGenerator struct {
+	defs map[string]*Schema
+}
+
+func (g *schemaGenerator) of(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.of(t.Elem())
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.of(t.Elem())}
+	case reflect.Struct:
+		return g.ref(t)
+	}
+	return &Schema{}
+}
+
+func (g *schemaGenerator) ref(t reflect.Type) *Schema {
+	name := t.Name()
+	if _, ok := g.defs[name]; !ok {
+		if def, ok := predefined[name]; ok {
+			g.defs[name] = def
+		} else {
+			// reserve the name first, the struct might refer to itself
+			g.defs[name] = &Schema{}
+			*g.defs[name] = *g.object(t)
+		}
+	}
+	return &Schema{Ref: "#/$defs/" + name}
+}
+
+func (g *schemaGenerator) object(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: falseSchema,
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, flags, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if flags == "inline" {
+			inline := g.object(field.Type)
+			for k, v := range inline.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, inline.Required...)
+			continue
+		}
+		if len(name) == 0 {
+			name = strings.ToLower(field.Name)
+		}
+
+		var prop *Schema
+		opts := strings.Split(field.Tag.Get("schema"), ",")
+		for _, opt := range opts {
+			if def, ok := strings.CutPrefix(opt, "def="); ok {
+				g.defs[def] = predefined[def]
+				prop = &Schema{Ref: "#/$defs/" + def}
+			}
+		}
+		if prop == nil {
+			prop = g.of(field.Type)
+		}
+		for _, opt := range opts {
+			switch {
+			case opt == "required":
+				s.Required = append(s.Required, name)
+			case strings.HasPrefix(opt, "enum="):
+				prop.Enum = strings.Split(strings.TrimPrefix(opt, "enum="), "|")
+			}
+		}
+		s.Properties[name] = prop
+	}
+	return s
+}
+
+// resolve follows $ref until it reaches a concrete schema.
+func (s *Schema) resolve(root *Schema) *Schema {
+	for len(s.Ref) > 0 {
+		def, ok := root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			panic(fmt.Sprintf("schema: unknown reference %s", s.Ref))
+		}
+		s = def
+	}
+	return s
+}
+
+// validate checks node against the schema and appends every problem found to errs.
+func (s *Schema) validate(root *Schema, node *yaml.Node, path string, errs *[]*Error) {
+	s = s.resolve(root)
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			s.validate(root, node.Content[0], path, errs)
+		}
+		return
+	case yaml.AliasNode:
+		s.validate(root, node.Alias, path, errs)
+		return
+	}
+	// an empty value decodes into the zero value of any type
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+
+	report := func(node *yaml.Node, path, format string, v ...any) {
+		*errs = append(*errs, &Error{Pos: posOf(node), Path: path, Msg: fmt.Sprintf(format, v...)})
+	}
+
+	if s.Not != nil {
+		nested := []*Error{}
+		s.Not.validate(root, node, path, &nested)
+		if len(nested) == 0 {
+			report(node, path, "value is not allowed")
+		}
+		return
+	}
+
+	if len(s.OneOf) > 0 {
+		kinds := []string{}
+		for _, branch := range s.OneOf {
+			b := branch.resolve(root)
+			if b.accepts(node) {
+				b.validate(root, node, path, errs)
+				return
+			}
+			kinds = append(kinds, b.Type)
+		}
+		report(node, path, "expected %s, got %s", strings.Join(kinds, " or "), kindOf(node))
+		return
+	}
+
+	if len(s.Type) > 0 && !s.accepts(node) {
+		report(node, path, "expected %s, got %s", s.Type, kindOf(node))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if e == node.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report(node, path, "unsupported value %q, expected one of %s", node.Value, strings.Join(s.Enum, ", "))
+		}
+	}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		if s.Items == nil {
+			return
+		}
+		for i, item := range node.Content {
+			s.Items.validate(root, item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case yaml.MappingNode:
+		seen := map[string]struct{}{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			seen[key.Value] = struct{}{}
+			if prop, ok := s.Properties[key.Value]; ok {
+				prop.validate(root, value, joinPath(path, key.Value), errs)
+				continue
+			}
+			if s.AdditionalProperties == falseSchema {
+				report(key, path, "unknown key %q", key.Value)
+				continue
+			}
+			if s.AdditionalProperties != nil {
+				s.AdditionalProperties.validate(root, value, joinPath(path, key.Value), errs)
+			}
+		}
+		missing := []string{}
+		for _, name := range s.Required {
+			if _, ok := seen[name]; !ok {
+				missing = append(missing, name)
+			}
+		}
+		sort.Strings(missing)
+		for _, name := range missing {
+			report(node, path, "missing required key %q", name)
+		}
+	}
+}
+
+// accepts reports whether the kind of node matches the type of the schema.
+func (s *Schema) accepts(node *yaml.Node) bool {
+	switch s.Type {
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	case "string":
+		return node.Kind == yaml.ScalarNode
+	case "boolean":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!bool"
+	case "integer":
+		return node.Kind == yaml.ScalarNode && node.Tag == "!!int"
+	case "number":
+		return node.Kind == yaml.ScalarNode && (node.Tag == "!!int" || node.Tag == "!!float")
+	}
+	return true
+}
+
+func kindOf(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.Tag {
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	}
+	return "string"
+}
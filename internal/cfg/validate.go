@@ -0,0 +1,384 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"aliax/internal/aos"
+	"aliax/internal/cond"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidateFile loads the configuration file with its includes and returns
+// every problem found in them. The returned error is only set when a file can't be read.
+func ValidateFile(name string) ([]*Error, error) {
+	file, err := Load(name)
+	if err != nil {
+		var errs Errors
+		if errors.As(err, &errs) {
+			return sortErrors(errs), nil
+		}
+		return nil, err
+	}
+
+	errs := []*Error{}
+	for _, f := range file.Files() {
+		data, err := aos.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range validateSchema(f, data) {
+			e.File = f
+			errs = append(errs, e)
+		}
+	}
+	for _, f := range file.Outdated() {
+		errs = append(errs, &Error{
+			Pos:  Pos{File: f},
+			Path: "version",
+			Msg:  fmt.Sprintf("the file is written for an older schema, run aliax migrate to upgrade it to version %d", CurrentVersion),
+		})
+	}
+	errs = append(errs, file.check()...)
+	return sortErrors(errs), nil
+}
+
+// Validate checks a single configuration document against the generated schema,
+// decodes it strictly and then runs the semantic checks which can't be
+// expressed by the schema. Problems are sorted by their position.
+func Validate(data []byte) []*Error {
+	errs := validateSchema("", data)
+	var file Aliax
+	if err := yaml.Unmarshal(data, &file); err == nil {
+		// the semantic checks run even when the schema reports problems,
+		// so that a single run lists everything that needs to be fixed.
+		errs = append(errs, file.check()...)
+	}
+	return sortErrors(errs)
+}
+
+// validateSchema checks the document against the schema and decodes it strictly,
+// name is only used to detect the format of the document.
+func validateSchema(name string, data []byte) []*Error {
+	node, err := parse(name, data)
+	if err != nil {
+		return []*Error{syntaxError(err)}
+	}
+
+	errs := []*Error{}
+	schema := GenerateSchema()
+	schema.validate(schema, node, "", &errs)
+	if len(errs) > 0 || isTOML(name) {
+		// unknown keys are already reported by the schema,
+		// a TOML document has no YAML source to decode strictly.
+		return errs
+	}
+
+	var file Aliax
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(&file)
+	var typeErr *yaml.TypeError
+	switch {
+	case errors.As(err, &typeErr):
+		errs = append(errs, fromTypeError("", typeErr, node)...)
+	case err != nil && !errors.Is(err, io.EOF):
+		errs = append(errs, syntaxError(err))
+	}
+	return errs
+}
+
+func sortErrors(errs []*Error) []*Error {
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].File != errs[j].File {
+			return errs[i].File < errs[j].File
+		}
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+	return errs
+}
+
+var (
+	syntaxErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+	tomlErrorLine   = regexp.MustCompile(`^toml: line \d+( \(last key "[^"]*"\))?: `)
+)
+
+func syntaxError(err error) *Error {
+	e := &Error{Msg: err.Error()}
+	var tomlErr toml.ParseError
+	if errors.As(err, &tomlErr) {
+		e.Line = tomlErr.Position.Line
+		e.Msg = tomlErrorLine.ReplaceAllString(e.Msg, "")
+		return e
+	}
+	if matched := syntaxErrorLine.FindStringSubmatch(err.Error()); matched != nil {
+		e.Line, _ = strconv.Atoi(matched[1])
+		e.Msg = matched[2]
+	}
+	return e
+}
+
+// check runs the semantic checks over a decoded configuration.
+func (a *Aliax) check() []*Error {
+	errs := []*Error{}
+	for _, k := range SortedKeys(a.Env) {
+		if !envName.MatchString(k) {
+			errs = append(errs, &Error{
+				Pos:  Pos{File: a.Source("env", k)},
+				Path: "env",
+				Msg:  fmt.Sprintf("invalid environment variable name %q", k),
+			})
+		}
+	}
+	for _, name := range SortedKeys(a.Extend) {
+		errs = append(errs, a.Extend[name].check(joinPath("extend", name), false)...)
+	}
+	for _, name := range SortedKeys(a.Command) {
+		if ext, ok := a.Extend[name]; ok {
+			errs = append(errs, &Error{
+				Pos:  a.Command[name].position(""),
+				Path: joinPath("command", name),
+				Msg:  fmt.Sprintf("command is also declared in extend at %s", ext.position(a.Source("extend", name))),
+			})
+		}
+		errs = append(errs, a.Command[name].check(joinPath("command", name), true)...)
+	}
+	for _, name := range SortedKeys(a.Script) {
+		sc := a.Script[name]
+		if sc.Cmd != nil {
+			errs = append(errs, sc.Cmd.check(joinPath("script", name), false)...)
+		}
+		errs = append(errs, a.checkScriptRefs(name, "deps", sc.Deps)...)
+		errs = append(errs, a.checkScriptRefs(name, "parallel", sc.Parallel)...)
+		errs = append(errs, a.checkScriptGlobs(name, "sources", sc.Sources)...)
+		errs = append(errs, a.checkScriptGlobs(name, "generates", sc.Generates)...)
+		errs = append(errs, a.checkScriptGlobs(name, "watch", sc.Watch)...)
+		errs = append(errs, a.checkScriptGlobs(name, "ignore", sc.Ignore)...)
+		errs = append(errs, checkRetry(sc.pos, joinPath("script", name), sc.Timeout, &sc.Retries, sc.RetryDelay)...)
+		errs = append(errs, checkWhen(sc.pos, joinPath("script", name), sc.When)...)
+	}
+	return errs
+}
+
+// checkScriptGlobs reports the malformed glob patterns of the key of the named script.
+func (a *Aliax) checkScriptGlobs(name, key string, patterns []string) []*Error {
+	errs := []*Error{}
+	for i, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, &Error{
+				Pos:  a.Script[name].pos,
+				Path: joinPath("script", name) + fmt.Sprintf(".%s[%d]", key, i),
+				Msg:  fmt.Sprintf("invalid glob pattern %q", pattern),
+			})
+		}
+	}
+	return errs
+}
+
+// checkScriptRefs reports the entries of the key of the named script
+// which don't refer to a declared script.
+func (a *Aliax) checkScriptRefs(name, key string, refs []string) []*Error {
+	errs := []*Error{}
+	for i, ref := range refs {
+		if _, ok := a.Script[ref]; !ok {
+			errs = append(errs, &Error{
+				Pos:  a.Script[name].pos,
+				Path: joinPath("script", name) + fmt.Sprintf(".%s[%d]", key, i),
+				Msg:  fmt.Sprintf("unknown script %q", ref),
+			})
+		}
+	}
+	return errs
+}
+
+// checkRetry reports the malformed timeout and retry options of a script or match case.
+func checkRetry(pos Pos, path, timeout string, retries *int, delay string) []*Error {
+	errs := []*Error{}
+	for _, d := range []struct{ key, value string }{{"timeout", timeout}, {"retry_delay", delay}} {
+		if len(d.value) == 0 {
+			continue
+		}
+		if v, err := time.ParseDuration(d.value); err != nil || v <= 0 {
+			errs = append(errs, &Error{Pos: pos, Path: joinPath(path, d.key), Msg: fmt.Sprintf("invalid duration %q, expected a positive duration such as 30s", d.value)})
+		}
+	}
+	if retries != nil && *retries < 0 {
+		errs = append(errs, &Error{Pos: pos, Path: joinPath(path, "retries"), Msg: "retries can't be negative"})
+	}
+	return errs
+}
+
+// checkWhen reports a malformed condition of a script or match case.
+func checkWhen(pos Pos, path, when string) []*Error {
+	if len(when) == 0 {
+		return nil
+	}
+	if _, err := cond.Parse(when); err != nil {
+		return []*Error{{Pos: pos, Path: joinPath(path, "when"), Msg: fmt.Sprintf("invalid condition: %s", err)}}
+	}
+	return nil
+}
+
+// check validates the flags, arguments and match cases of the command and its subcommands.
+// help reports whether a help flag is generated for the command.
+func (c *Command) check(path string, help bool) []*Error {
+	if c == nil {
+		return nil
+	}
+	errs := []*Error{}
+	report := func(pos Pos, path, format string, v ...any) {
+		errs = append(errs, &Error{Pos: pos, Path: path, Msg: fmt.Sprintf(format, v...)})
+	}
+
+	names := map[string]Pos{}
+	aliases := map[string]string{}
+	if help && !c.DisableHelp {
+		names["help"] = Pos{}
+		aliases["-h"] = "help"
+		aliases["--help"] = "help"
+	}
+	for i, flag := range c.Flags {
+		flagPath := joinPath(path, fmt.Sprintf("flags[%d]", i))
+		if prev, ok := names[flag.Name]; ok {
+			if prev.Line == 0 {
+				report(flag.pos, flagPath, "flag %q conflicts with the generated help flag, set disableHelp or rename it", flag.Name)
+			} else {
+				report(flag.pos, flagPath, "flag %q is already declared at %s", flag.Name, prev)
+			}
+		} else {
+			names[flag.Name] = flag.pos
+		}
+
+		switch {
+		case flag.Type == "enum" && len(flag.Choices) == 0:
+			report(flag.pos, flagPath, "enum flag %q needs a list of choices", flag.Name)
+		case flag.Type != "enum" && len(flag.Choices) > 0:
+			report(flag.pos, flagPath, "choices are only supported by enum flags")
+		}
+
+		switch {
+		case flag.Type == "bool" && flag.Required:
+			report(flag.pos, flagPath, "bool flag %q can't be required", flag.Name)
+		case flag.Type == "bool" && flag.Default != nil:
+			report(flag.pos, flagPath, "bool flag %q can't have a default value", flag.Name)
+		case flag.Required && flag.Default != nil:
+			report(flag.pos, flagPath, "required flag %q can't have a default value", flag.Name)
+		}
+		if _, ok := flag.Default.([]any); ok && flag.Type != "list" {
+			report(flag.pos, flagPath, "only list flags accept a list as default value")
+		} else if flag.Type != "bool" {
+			for _, v := range flag.Defaults() {
+				if err := flag.CheckValue(v); err != nil {
+					report(flag.pos, flagPath, "default: %s", err)
+				}
+			}
+		}
+		if len(flag.Env) > 0 && !envName.MatchString(flag.Env) {
+			report(flag.pos, flagPath, "invalid environment variable name %q", flag.Env)
+		}
+
+		alias := flag.Alias
+		if len(alias) == 0 {
+			alias = []string{flag.Name}
+		}
+		for _, a := range alias {
+			if owner, ok := aliases[a]; ok {
+				report(flag.pos, flagPath, "alias %q is already used by flag %q", a, owner)
+				continue
+			}
+			aliases[a] = flag.Name
+		}
+	}
+
+	var optional *Arg
+	for i := range c.Args {
+		arg := &c.Args[i]
+		argPath := joinPath(path, fmt.Sprintf("args[%d]", i))
+		if prev, ok := names[arg.Name]; ok {
+			if prev.Line == 0 {
+				report(arg.pos, argPath, "argument %q conflicts with the generated help flag", arg.Name)
+			} else {
+				report(arg.pos, argPath, "argument %q conflicts with the name declared at %s", arg.Name, prev)
+			}
+		} else {
+			names[arg.Name] = arg.pos
+		}
+		if arg.Variadic && i != len(c.Args)-1 {
+			report(arg.pos, argPath, "only the last argument can be variadic")
+		}
+		if !arg.Required {
+			if optional == nil {
+				optional = arg
+			}
+		} else if optional != nil {
+			report(arg.pos, argPath, "required argument %q can't follow optional argument %q", arg.Name, optional.Name)
+		}
+	}
+
+	types := map[string]string{}
+	for _, flag := range c.Flags {
+		types[flag.Name] = flag.Type
+	}
+	for i, matchCase := range c.Match {
+		patternPath := joinPath(path, fmt.Sprintf("match[%d].pattern", i))
+		for _, name := range matchCase.Names() {
+			if _, ok := names[name]; !ok {
+				report(matchCase.pos, patternPath, "pattern refers to undeclared flag or argument %q", name)
+			}
+		}
+		tests, err := matchCase.Tests()
+		if err != nil {
+			report(matchCase.pos, patternPath, "%s", err)
+		}
+		for _, t := range tests {
+			switch {
+			case len(t.Op) == 0:
+			case types[t.Name] == "list":
+				report(matchCase.pos, patternPath, "list flag %q can only be tested for being set", t.Name)
+			case types[t.Name] == "bool" && (t.Op != "==" || t.Values[0] != "true" && t.Values[0] != "false"):
+				report(matchCase.pos, patternPath, "bool flag %q can only be compared with true or false", t.Name)
+			}
+		}
+		errs = append(errs, checkRetry(matchCase.pos, joinPath(path, fmt.Sprintf("match[%d]", i)), matchCase.Timeout, matchCase.Retries, matchCase.RetryDelay)...)
+		errs = append(errs, checkWhen(matchCase.pos, joinPath(path, fmt.Sprintf("match[%d]", i)), matchCase.When)...)
+	}
+
+	for _, k := range SortedKeys(c.Env) {
+		if !envName.MatchString(k) {
+			report(c.pos, joinPath(path, "env"), "invalid environment variable name %q", k)
+		}
+	}
+
+	for _, name := range SortedKeys(c.Command) {
+		// the help flag is only generated for the top level command
+		errs = append(errs, c.Command[name].check(joinPath(path, "command."+name), false)...)
+	}
+	return errs
+}
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SortedKeys returns the keys of m in order.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,252 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	errs := Validate([]byte(`
+extend:
+  git:
+    flags:
+      - name: message
+        alias: [-m]
+        type: strnig
+      - name: amend
+        alias: [-m]
+        type: bool
+    match:
+      - pattern: [message, all]
+        platform: linux
+        run: git commit
+    typo: true
+command:
+  git:
+    flags:
+      - name: help
+        type: bool
+script:
+  build: go build
+  release:
+    mtch: []
+`))
+	msgs := []string{}
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	assert.Equal(t, []string{
+		`7:15: extend.git.flags[0].type: unsupported value "strnig", expected one of string, bool, int, float, enum, duration, list`,
+		`8:9: extend.git.flags[1]: alias "-m" is already used by flag "message"`,
+		`12:9: extend.git.match[0].pattern: pattern refers to undeclared flag or argument "all"`,
+		`13:19: extend.git.match[0].platform: unsupported value "linux", expected one of bash, fish, powershell, zsh, batch, windows, posix`,
+		`15:5: extend.git: unknown key "typo"`,
+		`18:5: command.git: command is also declared in extend at 4:5`,
+		`19:9: command.git.flags[0]: flag "help" conflicts with the generated help flag, set disableHelp or rename it`,
+		`24:5: script.release: unknown key "mtch"`,
+	}, msgs)
+}
+
+func TestValidateSyntax(t *testing.T) {
+	errs := Validate([]byte("script:\n\tbuild: go\n"))
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, 2, errs[0].Line, errs[0].Error())
+	}
+}
+
+func TestGenerateSchema(t *testing.T) {
+	data, err := json.Marshal(GenerateSchema())
+	assert.NoError(t, err)
+
+	var schema map[string]any
+	assert.NoError(t, json.Unmarshal(data, &schema))
+	defs := schema["$defs"].(map[string]any)
+	for _, name := range []string{"Command", "Flag", "Case", "Pattern", "Script"} {
+		assert.Contains(t, defs, name)
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	errs := Validate([]byte(`
+command:
+  cp:
+    flags:
+      - name: force
+        type: bool
+    args:
+      - name: force
+      - name: src
+        required: true
+        variadic: true
+      - name: dst
+        required: true
+    match:
+      - pattern: _
+        run: cp {{.src}} {{.dst}}
+`))
+	msgs := []string{}
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	assert.Equal(t, []string{
+		`8:9: command.cp.args[0]: argument "force" conflicts with the name declared at 5:9`,
+		`9:9: command.cp.args[1]: only the last argument can be variadic`,
+		`9:9: command.cp.args[1]: required argument "src" can't follow optional argument "force"`,
+		`12:9: command.cp.args[2]: required argument "dst" can't follow optional argument "force"`,
+	}, msgs)
+}
+
+func TestValidateFlagDefaults(t *testing.T) {
+	errs := Validate([]byte(`
+command:
+  deploy:
+    flags:
+      - name: replicas
+        type: int
+        default: many
+      - name: dry
+        type: bool
+        required: true
+        env: DRY-RUN
+      - name: env
+        type: enum
+        choices: [dev, prod]
+        default: [dev]
+      - name: token
+        type: string
+        required: true
+        default: secret
+      - name: tags
+        type: list
+        default: [a, b]
+        env: TAGS
+`))
+	msgs := []string{}
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	assert.Equal(t, []string{
+		`5:9: command.deploy.flags[0]: default: invalid value "many" for flag replicas: expected int`,
+		`8:9: command.deploy.flags[1]: bool flag "dry" can't be required`,
+		`8:9: command.deploy.flags[1]: invalid environment variable name "DRY-RUN"`,
+		`12:9: command.deploy.flags[2]: only list flags accept a list as default value`,
+		`16:9: command.deploy.flags[3]: required flag "token" can't have a default value`,
+	}, msgs)
+}
+
+func TestValidateScriptRefs(t *testing.T) {
+	errs := Validate([]byte(`
+env:
+  GO-FLAGS: -v
+command:
+  deploy:
+    env:
+      2FA: "1"
+script:
+  lint: golangci-lint run
+  ci:
+    deps: [gen]
+    parallel: [lint, tset]
+    sources: ["**/*.go", "cli/[a-"]
+`))
+	msgs := []string{}
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	assert.Equal(t, []string{
+		`env: invalid environment variable name "GO-FLAGS"`,
+		`6:5: command.deploy.env: invalid environment variable name "2FA"`,
+		`11:5: script.ci.deps[0]: unknown script "gen"`,
+		`11:5: script.ci.parallel[1]: unknown script "tset"`,
+		`11:5: script.ci.sources[1]: invalid glob pattern "cli/[a-"`,
+	}, msgs)
+}
+
+func TestValidateRetry(t *testing.T) {
+	errs := Validate([]byte(`
+script:
+  release:
+    timeout: 10 minutes
+    retries: -1
+    match:
+      - run: goreleaser
+        retry_delay: 0s
+  test:
+    timeout: 5m
+    retries: 2
+    retry_delay: 1s
+    match:
+      - run: go test ./...
+        timeout: 1m
+`))
+	msgs := []string{}
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	assert.Equal(t, []string{
+		`4:5: script.release.timeout: invalid duration "10 minutes", expected a positive duration such as 30s`,
+		`4:5: script.release.retries: retries can't be negative`,
+		`7:9: script.release.match[0].retry_delay: invalid duration "0s", expected a positive duration such as 30s`,
+	}, msgs)
+}
+
+func TestValidateWhen(t *testing.T) {
+	errs := Validate([]byte(`
+script:
+  release:
+    when: env.CI and
+    match:
+      - run: goreleaser
+        when: os == plan9
+      - run: goreleaser --snapshot
+        when: not env.CI
+`))
+	msgs := []string{}
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	assert.Equal(t, []string{
+		`4:5: script.release.when: invalid condition: unexpected end of condition`,
+		`6:9: script.release.match[0].when: invalid condition: unknown os "plan9", expected one of darwin, freebsd, linux, windows`,
+	}, msgs)
+}
+
+func TestValidatePattern(t *testing.T) {
+	errs := Validate([]byte(`
+command:
+  deploy:
+    flags:
+      - name: env
+        type: string
+      - name: force
+        type: bool
+      - name: tags
+        type: list
+    match:
+      - pattern: {env: "~(prod", force: true}
+        run: deploy
+      - pattern: {force: "yes"}
+        run: deploy
+      - pattern: {env: {nope: 1}}
+        run: deploy
+      - pattern: {tags: [a, b]}
+        run: deploy
+      - pattern: {env: [dev, prod], force: {not: false}, tags: null}
+        run: deploy
+`))
+	msgs := []string{}
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	assert.Equal(t, []string{
+		`12:9: command.deploy.match[0].pattern: env: invalid regular expression "(prod"`,
+		`14:9: command.deploy.match[1].pattern: bool flag "force" can only be compared with true or false`,
+		`16:9: command.deploy.match[2].pattern: env: expected a value, a list of values or {not: test}`,
+		`18:9: command.deploy.match[3].pattern: list flag "tags" can only be tested for being set`,
+	}, msgs)
+}
@@ -0,0 +1,218 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"aliax/internal/aos"
+)
+
+// Work is the content of an aliax.work file. Like go.work, it lists the
+// configurations making up the workspace with use directives and selects the
+// one used by default with the active directive:
+//
+//	use (
+//		./aliax.yaml
+//		./team/aliax.yaml
+//	)
+//
+//	active ./aliax.yaml
+//
+// Paths are relative to the directory containing the aliax.work file.
+// A file holding nothing but a single path is read as the legacy format,
+// which both uses and activates that path.
+type Work struct {
+	Use    []string
+	Active string
+}
+
+// ParseWork parses the content of an aliax.work file.
+func ParseWork(data []byte) (*Work, error) {
+	w := &Work{}
+	fields := strings.Fields(stripComment(string(data)))
+	if len(fields) == 1 && !isWorkDirective(fields[0]) {
+		w.Use = []string{fields[0]}
+		w.Active = fields[0]
+		return w, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	block := false
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if len(line) == 0 {
+			continue
+		}
+		if block {
+			if line == ")" {
+				block = false
+				continue
+			}
+			path, err := unquotePath(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", work, n, err)
+			}
+			w.Use = append(w.Use, path)
+			continue
+		}
+		directive, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+		switch directive {
+		case "use":
+			if arg == "(" {
+				block = true
+				continue
+			}
+			path, err := unquotePath(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", work, n, err)
+			}
+			w.Use = append(w.Use, path)
+		case "active":
+			path, err := unquotePath(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", work, n, err)
+			}
+			w.Active = path
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown directive %q", work, n, directive)
+		}
+	}
+	if block {
+		return nil, fmt.Errorf("%s: use block is not closed", work)
+	}
+	return w, scanner.Err()
+}
+
+func isWorkDirective(s string) bool {
+	return s == "use" || s == "active"
+}
+
+func stripComment(line string) string {
+	if i := strings.Index(line, "//"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+func unquotePath(s string) (string, error) {
+	if len(s) == 0 {
+		return "", fmt.Errorf("missing path")
+	}
+	if strings.HasPrefix(s, `"`) {
+		return strconv.Unquote(s)
+	}
+	if strings.ContainsAny(s, " \t") {
+		return "", fmt.Errorf("path %q must be quoted", s)
+	}
+	return s, nil
+}
+
+func quotePath(s string) string {
+	if strings.ContainsAny(s, " \t\"") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// Format returns the canonical text of the work file.
+func (w *Work) Format() []byte {
+	buf := &bytes.Buffer{}
+	switch len(w.Use) {
+	case 0:
+	case 1:
+		fmt.Fprintf(buf, "use %s\n", quotePath(w.Use[0]))
+	default:
+		buf.WriteString("use (\n")
+		for _, use := range w.Use {
+			fmt.Fprintf(buf, "\t%s\n", quotePath(use))
+		}
+		buf.WriteString(")\n")
+	}
+	if len(w.Active) > 0 {
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(buf, "active %s\n", quotePath(w.Active))
+	}
+	return buf.Bytes()
+}
+
+// Uses reports whether the path is listed by a use directive.
+func (w *Work) Uses(path string) bool {
+	for _, use := range w.Use {
+		if filepath.Clean(use) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddUse appends the paths which aren't used yet.
+func (w *Work) AddUse(paths ...string) {
+	for _, path := range paths {
+		if !w.Uses(path) {
+			w.Use = append(w.Use, path)
+		}
+	}
+}
+
+// DropUse removes the paths from the use list, dropping the active
+// selection as well when it's removed.
+func (w *Work) DropUse(paths ...string) {
+	use := []string{}
+	for _, u := range w.Use {
+		drop := false
+		for _, path := range paths {
+			if filepath.Clean(u) == filepath.Clean(path) {
+				drop = true
+			}
+		}
+		if !drop {
+			use = append(use, u)
+		}
+	}
+	w.Use = use
+	if !w.Uses(w.Active) {
+		w.Active = ""
+	}
+}
+
+// Selected returns the configuration used by default,
+// the active one or else the first one in use.
+func (w *Work) Selected() string {
+	if len(w.Active) > 0 {
+		return w.Active
+	}
+	if len(w.Use) > 0 {
+		return w.Use[0]
+	}
+	return yml
+}
+
+// ReadWork reads and parses the work file.
+func ReadWork(name string) (*Work, error) {
+	data, err := aos.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return ParseWork(data)
+}
+
+// WriteWork writes the work file in its canonical format.
+func WriteWork(name string, w *Work) error {
+	fp, err := aos.Create(name)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	_, err = fp.Write(w.Format())
+	return err
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cfg
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWork(t *testing.T) {
+	w, err := ParseWork([]byte(`// workspace of the aliax repository
+use ./aliax.yaml
+use (
+	./cli/aliax.yaml // cli tooling
+	"./team a/aliax.yaml"
+)
+
+active ./cli/aliax.yaml
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"./aliax.yaml", "./cli/aliax.yaml", "./team a/aliax.yaml"}, w.Use)
+	assert.Equal(t, "./cli/aliax.yaml", w.Selected())
+	assert.Equal(t, `use (
+	./aliax.yaml
+	./cli/aliax.yaml
+	"./team a/aliax.yaml"
+)
+
+active ./cli/aliax.yaml
+`, string(w.Format()))
+
+	w.DropUse("cli/aliax.yaml")
+	assert.Equal(t, "./aliax.yaml", w.Selected())
+
+	_, err = ParseWork([]byte("require ./aliax.yaml\nuse ./a.yaml\n"))
+	assert.EqualError(t, err, `aliax.work:1: unknown directive "require"`)
+}
+
+func TestParseWorkLegacy(t *testing.T) {
+	w, err := ParseWork([]byte("aliax.dev.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"aliax.dev.yaml"}, w.Use)
+	assert.Equal(t, "aliax.dev.yaml", w.Active)
+}
+
+func TestLoadWork(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"aliax.work":     "use (\n\t./aliax.yaml\n\t./cli/aliax.yaml\n)\nactive ./cli/aliax.yaml\n",
+		"aliax.yaml":     "runPath: bin\ncommand:\n  hello:\n    match:\n      - run: echo hello\n",
+		"cli/aliax.yaml": "runPath: scripts\nextend:\n  hello:\n    bin: echo\n",
+	})
+	w, err := ReadWork(filepath.Join(dir, "aliax.work"))
+	assert.NoError(t, err)
+
+	_, err = LoadWork(filepath.Join(dir, "aliax.work"), w)
+	assert.EqualError(t, err, filepath.Join(dir, "aliax.yaml")+`:4:5: command.hello: command "hello" in `+
+		filepath.Join(dir, "aliax.yaml")+` conflicts with extend "hello" in `+filepath.Join(dir, "cli", "aliax.yaml"))
+
+	w.DropUse("aliax.yaml")
+	file, err := LoadWork(filepath.Join(dir, "aliax.work"), w)
+	assert.NoError(t, err)
+	assert.Equal(t, "scripts", file.RunPath)
+}
@@ -0,0 +1,198 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cond
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bashOS and bashArch match the output of uname -s and uname -m.
+var (
+	bashOS = map[string]string{
+		"darwin":  `^Darwin`,
+		"freebsd": `^FreeBSD`,
+		"linux":   `^Linux`,
+		"windows": `^(MINGW|MSYS|CYGWIN)`,
+	}
+	bashArch = map[string]string{
+		"386":   `^i[3-6]86$`,
+		"amd64": `^(x86_64|amd64)$`,
+		"arm":   `^arm(v|$)`,
+		"arm64": `^(aarch64|arm64)$`,
+	}
+)
+
+// Bash compiles the condition into an expression of the bash [[ ]] command,
+// resolve maps the paths tested by exists. Constants must be simplified first.
+func Bash(e Expr, resolve func(string) string) string {
+	switch e := e.(type) {
+	case *And:
+		return fmt.Sprintf("( %s && %s )", Bash(e.X, resolve), Bash(e.Y, resolve))
+	case *Or:
+		return fmt.Sprintf("( %s || %s )", Bash(e.X, resolve), Bash(e.Y, resolve))
+	case *Not:
+		return fmt.Sprintf("! ( %s )", Bash(e.X, resolve))
+	case *Is:
+		var test string
+		switch e.Fact {
+		case "os":
+			test = fmt.Sprintf(`"$(uname -s)" =~ %s`, bashOS[e.Value])
+		case "arch":
+			test = fmt.Sprintf(`"$(uname -m)" =~ %s`, bashArch[e.Value])
+		case "shell":
+			test = fmt.Sprintf(`%s == %s`, bashQuote("bash"), bashQuote(e.Value))
+		}
+		if e.Negate {
+			return fmt.Sprintf("! ( %s )", test)
+		}
+		return test
+	case *Env:
+		switch e.Op {
+		case "==", "!=":
+			test := fmt.Sprintf(`"${%s-}" %s %s`, e.Name, e.Op, bashQuote(e.Value))
+			// an unset variable expands to "" but equals no value
+			switch {
+			case len(e.Value) > 0:
+				return test
+			case e.Op == "==":
+				return fmt.Sprintf(`( -n "${%s+x}" && %s )`, e.Name, test)
+			}
+			return fmt.Sprintf(`( -z "${%s+x}" || %s )`, e.Name, test)
+		}
+		return fmt.Sprintf(`-n "${%s+x}"`, e.Name)
+	case *Exists:
+		return fmt.Sprintf("-e %s", bashQuote(resolve(e.Path)))
+	case Const:
+		if e {
+			return "1 -eq 1"
+		}
+		return "1 -eq 0"
+	}
+	return ""
+}
+
+func bashQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// psOS and psArch test the platform from PowerShell, both Windows PowerShell
+// and PowerShell 7.
+var (
+	psOS = map[string]string{
+		"darwin":  `($IsMacOS -eq $true)`,
+		"freebsd": `([System.Runtime.InteropServices.RuntimeInformation]::IsOSPlatform([System.Runtime.InteropServices.OSPlatform]::Create('FREEBSD')))`,
+		"linux":   `($IsLinux -eq $true)`,
+		"windows": `($env:OS -eq 'Windows_NT')`,
+	}
+	psArch = map[string]string{
+		"386":   "X86",
+		"amd64": "X64",
+		"arm":   "Arm",
+		"arm64": "Arm64",
+	}
+)
+
+// PowerShell compiles the condition into a PowerShell expression,
+// resolve maps the paths tested by exists. Constants must be simplified first.
+func PowerShell(e Expr, resolve func(string) string) string {
+	switch e := e.(type) {
+	case *And:
+		return fmt.Sprintf("(%s -and %s)", PowerShell(e.X, resolve), PowerShell(e.Y, resolve))
+	case *Or:
+		return fmt.Sprintf("(%s -or %s)", PowerShell(e.X, resolve), PowerShell(e.Y, resolve))
+	case *Not:
+		return fmt.Sprintf("(-not %s)", PowerShell(e.X, resolve))
+	case *Is:
+		var test string
+		switch e.Fact {
+		case "os":
+			test = psOS[e.Value]
+		case "arch":
+			test = fmt.Sprintf("([System.Runtime.InteropServices.RuntimeInformation]::OSArchitecture -eq %s)", psQuote(psArch[e.Value]))
+		case "shell":
+			test = fmt.Sprintf("(%s -eq %s)", psQuote("powershell"), psQuote(e.Value))
+		}
+		if e.Negate {
+			return fmt.Sprintf("(-not %s)", test)
+		}
+		return test
+	case *Env:
+		switch e.Op {
+		case "==":
+			return fmt.Sprintf("($env:%s -ceq %s)", e.Name, psQuote(e.Value))
+		case "!=":
+			return fmt.Sprintf("($env:%s -cne %s)", e.Name, psQuote(e.Value))
+		}
+		return fmt.Sprintf("(Test-Path env:%s)", e.Name)
+	case *Exists:
+		return fmt.Sprintf("(Test-Path -LiteralPath %s)", psQuote(resolve(e.Path)))
+	case Const:
+		if e {
+			return "$true"
+		}
+		return "$false"
+	}
+	return ""
+}
+
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Fish compiles the condition into a fish condition, resolve maps the paths
+// tested by exists. Constants must be simplified first.
+func Fish(e Expr, resolve func(string) string) string {
+	switch e := e.(type) {
+	case *And:
+		return fmt.Sprintf("begin; %s; and %s; end", Fish(e.X, resolve), Fish(e.Y, resolve))
+	case *Or:
+		return fmt.Sprintf("begin; %s; or %s; end", Fish(e.X, resolve), Fish(e.Y, resolve))
+	case *Not:
+		return fmt.Sprintf("not %s", Fish(e.X, resolve))
+	case *Is:
+		var test string
+		switch e.Fact {
+		case "os":
+			test = fmt.Sprintf("string match -qr -- %s (uname -s)", fishQuote(bashOS[e.Value]))
+		case "arch":
+			test = fmt.Sprintf("string match -qr -- %s (uname -m)", fishQuote(bashArch[e.Value]))
+		case "shell":
+			test = fmt.Sprintf("test %s = %s", fishQuote("fish"), fishQuote(e.Value))
+		}
+		if e.Negate {
+			return fmt.Sprintf("not %s", test)
+		}
+		return test
+	case *Env:
+		if len(e.Op) == 0 {
+			return fmt.Sprintf("set -q %s", e.Name)
+		}
+		op := "="
+		if e.Op == "!=" {
+			op = "!="
+		}
+		test := fmt.Sprintf(`test "$%s" %s %s`, e.Name, op, fishQuote(e.Value))
+		// an unset variable expands to "" but equals no value
+		switch {
+		case len(e.Value) > 0:
+			return test
+		case e.Op == "==":
+			return fmt.Sprintf("begin; set -q %s; and %s; end", e.Name, test)
+		}
+		return fmt.Sprintf("begin; not set -q %s; or %s; end", e.Name, test)
+	case *Exists:
+		return fmt.Sprintf("test -e %s", fishQuote(resolve(e.Path)))
+	case Const:
+		if e {
+			return "true"
+		}
+		return "false"
+	}
+	return ""
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
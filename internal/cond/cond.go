@@ -0,0 +1,303 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package cond implements the conditions of the when option of scripts and
+// match cases:
+//
+//	os == linux and arch != arm64
+//	shell == powershell or exists(go.mod)
+//	not env.CI and (env.GOFLAGS or env.MODE == "release")
+//
+// os, arch and shell are compared with == and != to the names used by Go
+// (linux, darwin, windows, amd64, arm64, ...) and to bash, fish, powershell or zsh.
+// env.NAME tests whether the variable is set, or compares its value, an unset
+// variable equals no value, not even "", so env.NAME != "" holds when it's unset.
+// exists(path) tests whether the file exists, relative paths are resolved
+// against the workspace root. and, or and not may also be written &&, || and !.
+//
+// Conditions are evaluated by Simplify, which only decides the tests whose
+// facts are known: the runner knows every fact, while the generators only
+// know the shell and compile what remains into native shell tests. The runner
+// runs the scripts with bash, or PowerShell on Windows, so shell is one of them
+// whatever $SHELL is.
+package cond
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Expr is a parsed condition.
+type Expr interface {
+	String() string
+}
+
+type (
+	// And holds when both X and Y hold.
+	And struct{ X, Y Expr }
+	// Or holds when X or Y holds.
+	Or struct{ X, Y Expr }
+	// Not holds when X doesn't.
+	Not struct{ X Expr }
+	// Is compares the os, arch or shell with Value.
+	Is struct {
+		Fact   string
+		Value  string
+		Negate bool
+	}
+	// Env tests whether the environment variable is set when Op is empty,
+	// or compares its value with Value when Op is == or !=.
+	Env struct {
+		Name  string
+		Op    string
+		Value string
+	}
+	// Exists tests whether the file exists.
+	Exists struct{ Path string }
+	// Const is a condition whose result is known.
+	Const bool
+)
+
+func (e *And) String() string  { return fmt.Sprintf("(%s and %s)", e.X, e.Y) }
+func (e *Or) String() string   { return fmt.Sprintf("(%s or %s)", e.X, e.Y) }
+func (e *Not) String() string  { return fmt.Sprintf("not %s", e.X) }
+func (e Const) String() string { return fmt.Sprint(bool(e)) }
+
+func (e *Is) String() string {
+	op := "=="
+	if e.Negate {
+		op = "!="
+	}
+	return fmt.Sprintf("%s %s %s", e.Fact, op, e.Value)
+}
+
+func (e *Env) String() string {
+	if len(e.Op) == 0 {
+		return "env." + e.Name
+	}
+	return fmt.Sprintf("env.%s %s %q", e.Name, e.Op, e.Value)
+}
+
+func (e *Exists) String() string { return fmt.Sprintf("exists(%q)", e.Path) }
+
+// Values lists the values accepted by the os, arch and shell tests.
+var Values = map[string][]string{
+	"os":    {"darwin", "freebsd", "linux", "windows"},
+	"arch":  {"386", "amd64", "arm", "arm64"},
+	"shell": {"bash", "fish", "powershell", "zsh"},
+}
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Parse parses a condition.
+func Parse(s string) (Expr, error) {
+	p := &parser{tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return e, nil
+}
+
+type token struct {
+	text string
+	// quoted is set for string literals, which are never operators.
+	quoted bool
+	err    error
+}
+
+// tokenize splits the condition into words, string literals and operators.
+func tokenize(s string) []token {
+	tokens := []token{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return append(tokens, token{err: fmt.Errorf("unterminated string %s", s[i:])})
+			}
+			tokens = append(tokens, token{text: s[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		case strings.HasPrefix(s[i:], "==") || strings.HasPrefix(s[i:], "!=") ||
+			strings.HasPrefix(s[i:], "&&") || strings.HasPrefix(s[i:], "||"):
+			tokens = append(tokens, token{text: s[i : i+2]})
+			i += 2
+		case c == '(' || c == ')' || c == '!':
+			tokens = append(tokens, token{text: s[i : i+1]})
+			i++
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\n()!=&|\"'", rune(s[i])) {
+				i++
+			}
+			if i == start {
+				return append(tokens, token{err: fmt.Errorf("unexpected %q", s[i:i+1])})
+			}
+			tokens = append(tokens, token{text: s[start:i]})
+		}
+	}
+	return tokens
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// is reports whether the next token is one of the operators.
+func (p *parser) is(ops ...string) bool {
+	if p.eof() || p.peek().quoted {
+		return false
+	}
+	for _, op := range ops {
+		if p.peek().text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) next() (token, error) {
+	if p.eof() {
+		return token{}, fmt.Errorf("unexpected end of condition")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, t.err
+}
+
+func (p *parser) expect(op string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t.quoted || t.text != op {
+		return fmt.Errorf("expected %q, found %q", op, t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("or", "||") {
+		p.pos++
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &Or{X: x, Y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("and", "&&") {
+		p.pos++
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = &And{X: x, Y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.is("not", "!") {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.quoted {
+		return nil, fmt.Errorf("unexpected string %q, expected a test", t.text)
+	}
+	switch {
+	case t.text == "(":
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	case t.text == "exists":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		path, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		return &Exists{Path: path.text}, p.expect(")")
+	case strings.HasPrefix(t.text, "env."):
+		e := &Env{Name: strings.TrimPrefix(t.text, "env.")}
+		if !envName.MatchString(e.Name) {
+			return nil, fmt.Errorf("invalid environment variable name %q", e.Name)
+		}
+		if p.is("==", "!=") {
+			e.Op = p.tokens[p.pos].text
+			p.pos++
+			v, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			e.Value = v.text
+		}
+		return e, nil
+	}
+	values, ok := Values[t.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown test %q, expected os, arch, shell, env.NAME or exists(path)", t.text)
+	}
+	if !p.is("==", "!=") {
+		return nil, fmt.Errorf("expected == or != after %s", t.text)
+	}
+	e := &Is{Fact: t.text, Negate: p.tokens[p.pos].text == "!="}
+	p.pos++
+	v, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	e.Value = v.text
+	for _, known := range values {
+		if known == e.Value {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown %s %q, expected one of %s", e.Fact, e.Value, strings.Join(values, ", "))
+}
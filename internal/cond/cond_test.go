@@ -0,0 +1,173 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cond
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for s, want := range map[string]string{
+		`os == linux and arch != arm64`:                      `(os == linux and arch != arm64)`,
+		`shell == powershell || exists(go.mod)`:              `(shell == powershell or exists("go.mod"))`,
+		`not env.CI && (env.GOFLAGS or env.MODE == release)`: `(not env.CI and (env.GOFLAGS or env.MODE == "release"))`,
+		`!exists('my file')`:                                 `not exists("my file")`,
+		`env.A or env.B and env.C`:                           `(env.A or (env.B and env.C))`,
+	} {
+		e, err := Parse(s)
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, want, e.String(), s)
+		}
+	}
+	for s, want := range map[string]string{
+		``:                   `empty condition`,
+		`os == plan9`:        `unknown os "plan9", expected one of darwin, freebsd, linux, windows`,
+		`os linux`:           `expected == or != after os`,
+		`host == a`:          `unknown test "host", expected os, arch, shell, env.NAME or exists(path)`,
+		`env.1A`:             `invalid environment variable name "1A"`,
+		`(env.A`:             `unexpected end of condition`,
+		`env.A env.B`:        `unexpected "env.B"`,
+		`env.A == "b`:        `unterminated string "b`,
+		`exists(go.mod`:      `unexpected end of condition`,
+		`"linux" == os`:      `unexpected string "linux", expected a test`,
+		`env.A and or env.B`: `unknown test "or", expected os, arch, shell, env.NAME or exists(path)`,
+	} {
+		_, err := Parse(s)
+		assert.EqualError(t, err, want, s)
+	}
+}
+
+func TestEval(t *testing.T) {
+	env := map[string]string{"CI": "true", "MODE": "release", "EMPTY": ""}
+	facts := &Facts{
+		OS:    "linux",
+		Arch:  "amd64",
+		Shell: "bash",
+		LookupEnv: func(name string) (string, bool) {
+			v, ok := env[name]
+			return v, ok
+		},
+		Exists: func(name string) bool { return name == "go.mod" },
+	}
+	for s, want := range map[string]bool{
+		`os == linux`:                         true,
+		`os != linux`:                         false,
+		`arch == amd64 and shell == bash`:     true,
+		`shell == powershell`:                 false,
+		`env.CI`:                              true,
+		`env.HOME`:                            false,
+		`env.MODE == release`:                 true,
+		`env.MODE != release`:                 false,
+		`env.HOME != x`:                       true,
+		`env.HOME == ""`:                      false,
+		`env.HOME != ""`:                      true,
+		`env.EMPTY == ""`:                     true,
+		`env.EMPTY != ""`:                     false,
+		`exists(go.mod) and !exists(go.work)`: true,
+		`not (os == windows or env.CI)`:       false,
+	} {
+		e, err := Parse(s)
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, want, Eval(e, facts), s)
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	facts := &Facts{Shell: "bash"}
+	for s, want := range map[string]string{
+		`shell == bash`:                  `true`,
+		`shell == powershell and env.CI`: `false`,
+		`shell == bash and env.CI`:       `env.CI`,
+		`shell != bash or os == darwin`:  `os == darwin`,
+		`not (shell == bash) or env.CI`:  `env.CI`,
+		`os == linux and exists(go.mod)`: `(os == linux and exists("go.mod"))`,
+		`not env.CI or shell == bash`:    `true`,
+	} {
+		e, err := Parse(s)
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, want, Simplify(e, facts).String(), s)
+		}
+	}
+	assert.Equal(t, `shell == bash`, Platform("bash").String())
+	assert.Equal(t, `os != windows`, Platform("posix").String())
+	assert.Equal(t, `true`, Platform("").String())
+}
+
+func TestCompile(t *testing.T) {
+	resolve := func(name string) string { return "/ws/" + name }
+	for s, want := range map[string][3]string{
+		`os == linux and arch != arm64`: {
+			`( "$(uname -s)" =~ ^Linux && ! ( "$(uname -m)" =~ ^(aarch64|arm64)$ ) )`,
+			`(($IsLinux -eq $true) -and (-not ([System.Runtime.InteropServices.RuntimeInformation]::OSArchitecture -eq 'Arm64')))`,
+			`begin; string match -qr -- '^Linux' (uname -s); and not string match -qr -- '^(aarch64|arm64)$' (uname -m); end`,
+		},
+		`env.CI or env.MODE == "it's"`: {
+			`( -n "${CI+x}" || "${MODE-}" == 'it'\''s' )`,
+			`((Test-Path env:CI) -or ($env:MODE -ceq 'it''s'))`,
+			`begin; set -q CI; or test "$MODE" = 'it\'s'; end`,
+		},
+		`env.A == "" or env.B != ""`: {
+			`( ( -n "${A+x}" && "${A-}" == '' ) || ( -z "${B+x}" || "${B-}" != '' ) )`,
+			`(($env:A -ceq '') -or ($env:B -cne ''))`,
+			`begin; begin; set -q A; and test "$A" = ''; end; or begin; not set -q B; or test "$B" != ''; end; end`,
+		},
+		`not exists(go.mod)`: {
+			`! ( -e '/ws/go.mod' )`,
+			`(-not (Test-Path -LiteralPath '/ws/go.mod'))`,
+			`not test -e '/ws/go.mod'`,
+		},
+		`os == windows and env.A != b`: {
+			`( "$(uname -s)" =~ ^(MINGW|MSYS|CYGWIN) && "${A-}" != 'b' )`,
+			`(($env:OS -eq 'Windows_NT') -and ($env:A -cne 'b'))`,
+			`begin; string match -qr -- '^(MINGW|MSYS|CYGWIN)' (uname -s); and test "$A" != 'b'; end`,
+		},
+	} {
+		e, err := Parse(s)
+		if assert.NoError(t, err, s) {
+			assert.Equal(t, want[0], Bash(e, resolve), s)
+			assert.Equal(t, want[1], PowerShell(e, resolve), s)
+			assert.Equal(t, want[2], Fish(e, resolve), s)
+		}
+	}
+}
+
+// TestCompileEnv runs the compiled tests of environment variables,
+// they must agree with Eval on unset and empty variables.
+func TestCompileEnv(t *testing.T) {
+	shells := map[string]func(string) []string{
+		"bash": func(test string) []string { return []string{"-c", "[[ " + test + " ]]"} },
+		"fish": func(test string) []string { return []string{"-c", test} },
+	}
+	compilers := map[string]func(Expr, func(string) string) string{"bash": Bash, "fish": Fish}
+	env := map[string]string{"EMPTY": "", "MODE": "release"}
+	facts := &Facts{LookupEnv: func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}}
+	environ := []string{"PATH=" + os.Getenv("PATH"), "EMPTY=", "MODE=release"}
+	for shell, args := range shells {
+		if _, err := exec.LookPath(shell); err != nil {
+			t.Logf("%s not found", shell)
+			continue
+		}
+		for _, s := range []string{
+			`env.UNSET == ""`, `env.UNSET != ""`, `env.EMPTY == ""`, `env.EMPTY != ""`,
+			`env.MODE == ""`, `env.MODE != ""`, `env.MODE == release`, `env.UNSET != release`,
+			`env.EMPTY`, `not env.UNSET`,
+		} {
+			e, err := Parse(s)
+			if !assert.NoError(t, err, s) {
+				continue
+			}
+			cmd := exec.Command(shell, args(compilers[shell](e, nil))...)
+			cmd.Env = environ
+			assert.Equal(t, Eval(e, facts), cmd.Run() == nil, "%s: %s", shell, s)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package cond
+
+// Facts describes the environment a condition is evaluated in,
+// the tests of the facts left empty are kept undecided.
+type Facts struct {
+	OS    string
+	Arch  string
+	Shell string
+	// LookupEnv returns the value of an environment variable.
+	LookupEnv func(string) (string, bool)
+	// Exists reports whether a file exists.
+	Exists func(string) bool
+}
+
+// Simplify decides the tests of e whose facts are known and returns what remains
+// of the condition, a Const when the result doesn't depend on unknown facts.
+func Simplify(e Expr, f *Facts) Expr {
+	switch e := e.(type) {
+	case *And:
+		x, y := Simplify(e.X, f), Simplify(e.Y, f)
+		switch {
+		case x == Const(false) || y == Const(false):
+			return Const(false)
+		case x == Const(true):
+			return y
+		case y == Const(true):
+			return x
+		}
+		return &And{X: x, Y: y}
+	case *Or:
+		x, y := Simplify(e.X, f), Simplify(e.Y, f)
+		switch {
+		case x == Const(true) || y == Const(true):
+			return Const(true)
+		case x == Const(false):
+			return y
+		case y == Const(false):
+			return x
+		}
+		return &Or{X: x, Y: y}
+	case *Not:
+		x := Simplify(e.X, f)
+		if c, ok := x.(Const); ok {
+			return !c
+		}
+		return &Not{X: x}
+	case *Is:
+		fact := map[string]string{"os": f.OS, "arch": f.Arch, "shell": f.Shell}[e.Fact]
+		if len(fact) == 0 {
+			return e
+		}
+		return Const((fact == e.Value) != e.Negate)
+	case *Env:
+		if f.LookupEnv == nil {
+			return e
+		}
+		v, ok := f.LookupEnv(e.Name)
+		switch e.Op {
+		case "==":
+			return Const(ok && v == e.Value)
+		case "!=":
+			return Const(!ok || v != e.Value)
+		}
+		return Const(ok)
+	case *Exists:
+		if f.Exists == nil {
+			return e
+		}
+		return Const(f.Exists(e.Path))
+	}
+	return e
+}
+
+// Eval reports whether the condition holds, the facts must all be known.
+func Eval(e Expr, f *Facts) bool {
+	return Simplify(e, f) == Const(true)
+}
+
+// Platform returns the condition the runner selects the cases of platform under,
+// the generated scripts only run the cases of their shell instead.
+func Platform(platform string) Expr {
+	switch platform {
+	case "bash", "fish", "powershell", "zsh":
+		return &Is{Fact: "shell", Value: platform}
+	case "windows", "batch":
+		return &Is{Fact: "os", Value: "windows"}
+	case "posix":
+		return &Is{Fact: "os", Value: "windows", Negate: true}
+	}
+	return Const(true)
+}
@@ -0,0 +1,292 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package dotenv parses dotenv files:
+//
+//	# comment
+//	export GOFLAGS=-trimpath
+//	NAME=aliax          # inline comment
+//	OUTPUT=${DIST:-dist}/$NAME
+//	GREETING="hello\n${NAME}"
+//	LITERAL='${NAME} is kept as is'
+//
+// Unquoted values end at an inline comment and are trimmed. Single-quoted
+// values are taken literally. Double-quoted values may span several lines
+// and support the \n, \r, \t, \", \$ and \\ escapes. ${VAR}, ${VAR:-default}
+// and $VAR are expanded in unquoted and double-quoted values, from the
+// variables declared above in the file and then from the lookup function.
+package dotenv
+
+import (
+	"aliax/internal/aos"
+	"fmt"
+	"strings"
+)
+
+// Var is a variable declared by a dotenv file.
+type Var struct {
+	Key   string
+	Value string
+}
+
+// ReadFile reads and parses the named dotenv file.
+func ReadFile(name string, lookup func(string) (string, bool)) ([]Var, error) {
+	data, err := aos.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := Parse(data, lookup)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", name, err)
+	}
+	return vars, nil
+}
+
+// Parse parses the content of a dotenv file and returns its variables in
+// declaration order, lookup resolves the variables the file doesn't declare.
+func Parse(data []byte, lookup func(string) (string, bool)) ([]Var, error) {
+	p := &parser{
+		src:      strings.ReplaceAll(string(data), "\r\n", "\n"),
+		line:     1,
+		declared: map[string]string{},
+		lookup:   lookup,
+	}
+	vars := []Var{}
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return vars, nil
+		}
+		line := p.line
+		v, err := p.parseVar()
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", line, err)
+		}
+		p.declared[v.Key] = v.Value
+		vars = append(vars, v)
+	}
+}
+
+type parser struct {
+	src      string
+	off      int
+	line     int
+	declared map[string]string
+	lookup   func(string) (string, bool)
+}
+
+func (p *parser) eof() bool {
+	return p.off >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	return p.src[p.off]
+}
+
+func (p *parser) next() byte {
+	c := p.src[p.off]
+	p.off++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+// skipBlank skips whitespace, empty lines and comment lines.
+func (p *parser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\n':
+			p.next()
+		case '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+func (p *parser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+func (p *parser) parseKey() string {
+	start := p.off
+	for !p.eof() && isKeyChar(p.peek()) {
+		p.next()
+	}
+	return p.src[start:p.off]
+}
+
+// isKeyChar reports whether c may appear in the name of a declared variable.
+func isKeyChar(c byte) bool {
+	return c == '.' || c == '-' || isNameChar(c)
+}
+
+// isNameChar reports whether c may appear in an unbraced $VAR reference.
+func isNameChar(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func (p *parser) parseVar() (Var, error) {
+	key := p.parseKey()
+	if key == "export" && !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skipSpaces()
+		key = p.parseKey()
+	}
+	if len(key) == 0 {
+		return Var{}, fmt.Errorf("unexpected character %q, expected a variable name", p.peek())
+	}
+	p.skipSpaces()
+	if p.eof() || p.peek() != '=' {
+		return Var{}, fmt.Errorf("missing = after %s", key)
+	}
+	p.next()
+	p.skipSpaces()
+
+	var (
+		value string
+		err   error
+	)
+	switch {
+	case p.eof() || p.peek() == '\n':
+	case p.peek() == '\'':
+		value, err = p.parseSingleQuoted()
+	case p.peek() == '"':
+		value, err = p.parseDoubleQuoted()
+	default:
+		value = p.expand(p.parseUnquoted())
+	}
+	if err != nil {
+		return Var{}, err
+	}
+	// only a comment may follow the value
+	p.skipSpaces()
+	if !p.eof() && p.peek() != '\n' && p.peek() != '#' {
+		return Var{}, fmt.Errorf("unexpected character %q after the value of %s", p.peek(), key)
+	}
+	p.skipLine()
+	return Var{Key: key, Value: value}, nil
+}
+
+func (p *parser) parseUnquoted() string {
+	start := p.off
+	for !p.eof() && p.peek() != '\n' {
+		if p.peek() == '#' && p.off > start && (p.src[p.off-1] == ' ' || p.src[p.off-1] == '\t') {
+			break
+		}
+		p.next()
+	}
+	return strings.TrimSpace(p.src[start:p.off])
+}
+
+func (p *parser) parseSingleQuoted() (string, error) {
+	p.next()
+	start := p.off
+	for !p.eof() {
+		if p.next() == '\'' {
+			return p.src[start : p.off-1], nil
+		}
+	}
+	return "", fmt.Errorf("unterminated single-quoted value")
+}
+
+func (p *parser) parseDoubleQuoted() (string, error) {
+	p.next()
+	sb := strings.Builder{}
+	for !p.eof() {
+		c := p.next()
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.eof() {
+				continue
+			}
+			e := p.next()
+			switch e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\', '$':
+				sb.WriteByte(e)
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			}
+		case '$':
+			sb.WriteString(p.expandAt())
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated double-quoted value")
+}
+
+// expand replaces the references to variables of an unquoted value.
+func (p *parser) expand(s string) string {
+	sub := &parser{src: s, declared: p.declared, lookup: p.lookup}
+	sb := strings.Builder{}
+	for !sub.eof() {
+		c := sub.next()
+		if c == '$' {
+			sb.WriteString(sub.expandAt())
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// expandAt expands the reference following a $ which was just consumed.
+func (p *parser) expandAt() string {
+	if p.eof() {
+		return "$"
+	}
+	if p.peek() == '{' {
+		end := strings.IndexByte(p.src[p.off:], '}')
+		if end < 0 {
+			return "$"
+		}
+		ref := p.src[p.off+1 : p.off+end]
+		for i := 0; i <= end; i++ {
+			p.next()
+		}
+		name, def, hasDef := strings.Cut(ref, ":-")
+		if v, ok := p.get(name); ok && (len(v) > 0 || !hasDef) {
+			return v
+		}
+		return def
+	}
+	start := p.off
+	for !p.eof() && isNameChar(p.peek()) {
+		p.next()
+	}
+	name := p.src[start:p.off]
+	if len(name) == 0 {
+		return "$"
+	}
+	v, _ := p.get(name)
+	return v
+}
+
+func (p *parser) get(name string) (string, bool) {
+	if v, ok := p.declared[name]; ok {
+		return v, true
+	}
+	if p.lookup != nil {
+		return p.lookup(name)
+	}
+	return "", false
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package dotenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	lookup := func(key string) (string, bool) {
+		v, ok := map[string]string{"HOME": "/home/aliax", "EMPTY": ""}[key]
+		return v, ok
+	}
+	vars, err := Parse([]byte(`# comment
+export GOFLAGS=-trimpath
+NAME = aliax   # inline comment
+URL=http://host/#anchor
+OUTPUT=${DIST:-dist}/$NAME-v1
+CACHE=${HOME}/.cache ${EMPTY:-none}
+GREETING="hello\n${NAME} \"quoted\" \$NAME"
+LITERAL='${NAME} is # kept'
+MULTI="a
+b"
+EMPTY_VALUE=
+
+windows.path=C:\bin
+`), lookup)
+	assert.NoError(t, err)
+	assert.Equal(t, []Var{
+		{"GOFLAGS", "-trimpath"},
+		{"NAME", "aliax"},
+		{"URL", "http://host/#anchor"},
+		{"OUTPUT", "dist/aliax-v1"},
+		{"CACHE", "/home/aliax/.cache none"},
+		{"GREETING", "hello\naliax \"quoted\" $NAME"},
+		{"LITERAL", "${NAME} is # kept"},
+		{"MULTI", "a\nb"},
+		{"EMPTY_VALUE", ""},
+		{"windows.path", `C:\bin`},
+	}, vars)
+}
+
+func TestParseError(t *testing.T) {
+	for data, msg := range map[string]string{
+		"A=1\nB":          "2: missing = after B",
+		"A='1":            "1: unterminated single-quoted value",
+		"A=\"1\nB=2":      "1: unterminated double-quoted value",
+		"A=\"1\" 2":       `1: unexpected character '2' after the value of A`,
+		"=1":              `1: unexpected character '=', expected a variable name`,
+		"\n\nexport =1\n": `3: unexpected character '=', expected a variable name`,
+	} {
+		_, err := Parse([]byte(data), nil)
+		assert.EqualError(t, err, msg, data)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package runner
+
+import (
+	"aliax/internal/aos"
+	"aliax/internal/cfg"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CacheFile is the file, relative to the workspace root, holding the
+// fingerprints of the scripts declaring sources or generates.
+var CacheFile = filepath.Join(".aliax", "cache.json")
+
+// fingerprint is the state of a script after its last successful run,
+// files are identified by their path relative to the workspace root.
+type fingerprint struct {
+	// Script is the checksum of the definition of the script,
+	// editing the script invalidates its previous runs.
+	Script string `json:"script"`
+	// Args and Env are the checksums of the arguments of the script and of
+	// the variables set by its dotenv files and env maps.
+	Args      string            `json:"args"`
+	Env       string            `json:"env"`
+	Sources   map[string]string `json:"sources"`
+	Generates map[string]string `json:"generates"`
+}
+
+// cache holds the fingerprints of the scripts of a workspace.
+type cache struct {
+	mu      sync.Mutex
+	name    string
+	Scripts map[string]*fingerprint `json:"scripts"`
+}
+
+// loadCache reads the cache of the workspace rooted at root,
+// a missing or corrupted cache is treated as an empty one.
+func loadCache(root string) *cache {
+	c := &cache{name: filepath.Join(root, CacheFile)}
+	if data, err := os.ReadFile(c.name); err == nil {
+		json.Unmarshal(data, c)
+	}
+	if c.Scripts == nil {
+		c.Scripts = map[string]*fingerprint{}
+	}
+	return c
+}
+
+func (c *cache) get(name string) *fingerprint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Scripts[name]
+}
+
+// set records the fingerprint of the script and writes the cache.
+func (c *cache) set(name string, fp *fingerprint) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Scripts[name] = fp
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = aos.MkdirAll(filepath.Dir(c.name), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.name, data, 0644)
+}
+
+// fingerprintOf computes the current fingerprint of the script
+// run with args and the declared variables env.
+func fingerprintOf(root string, sc cfg.Script, args []string, env map[string]string) (*fingerprint, error) {
+	def, err := yaml.Marshal(sc)
+	if err != nil {
+		return nil, err
+	}
+	vars := []string{}
+	for _, k := range cfg.SortedKeys(env) {
+		vars = append(vars, k+"="+env[k])
+	}
+	fp := &fingerprint{
+		Script: sum(def),
+		Args:   sum([]byte(strings.Join(args, "\x00"))),
+		Env:    sum([]byte(strings.Join(vars, "\x00"))),
+	}
+	if fp.Sources, err = checksums(root, sc.Sources); err != nil {
+		return nil, err
+	}
+	if fp.Generates, err = checksums(root, sc.Generates); err != nil {
+		return nil, err
+	}
+	return fp, nil
+}
+
+// changes explains why the script described by cur must run again
+// given its fingerprint prev, it's empty when the script is up to date.
+func changes(prev, cur *fingerprint) []string {
+	if prev == nil {
+		return []string{"no previous run"}
+	}
+	reasons := []string{}
+	if prev.Script != cur.Script {
+		reasons = append(reasons, "script changed")
+	}
+	if prev.Args != cur.Args {
+		reasons = append(reasons, "arguments changed")
+	}
+	if prev.Env != cur.Env {
+		reasons = append(reasons, "environment changed")
+	}
+	reasons = append(reasons, diffFiles("source", prev.Sources, cur.Sources)...)
+	if len(cur.Generates) == 0 && len(prev.Generates) == 0 {
+		return reasons
+	}
+	return append(reasons, diffFiles("generated file", prev.Generates, cur.Generates)...)
+}
+
+func diffFiles(kind string, prev, cur map[string]string) []string {
+	reasons := []string{}
+	for _, name := range cfg.SortedKeys(cur) {
+		sum, ok := prev[name]
+		switch {
+		case !ok:
+			reasons = append(reasons, fmt.Sprintf("%s %s added", kind, name))
+		case sum != cur[name]:
+			reasons = append(reasons, fmt.Sprintf("%s %s changed", kind, name))
+		}
+	}
+	for _, name := range cfg.SortedKeys(prev) {
+		if _, ok := cur[name]; !ok {
+			reasons = append(reasons, fmt.Sprintf("%s %s removed", kind, name))
+		}
+	}
+	return reasons
+}
+
+// checksums returns the SHA-256 of the files matched by the patterns,
+// keyed by their slash separated path relative to root.
+func checksums(root string, patterns []string) (map[string]string, error) {
+	sums := map[string]string{}
+	for _, pattern := range patterns {
+		files, err := glob(root, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if _, ok := sums[file]; ok {
+				continue
+			}
+			sum, err := checksum(filepath.Join(root, filepath.FromSlash(file)))
+			if err != nil {
+				return nil, err
+			}
+			sums[file] = sum
+		}
+	}
+	return sums, nil
+}
+
+func sum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func checksum(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// glob returns the files below root matched by pattern, "**" matches any directories.
+func glob(root, pattern string) ([]string, error) {
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %q", pattern)
+	}
+	segments := strings.Split(pattern, "/")
+	// walk from the longest prefix free of meta characters
+	base := []string{}
+	for _, s := range segments {
+		if strings.ContainsAny(s, `*?[\`) {
+			break
+		}
+		base = append(base, s)
+	}
+
+	files := []string{}
+	start := filepath.Join(root, filepath.FromSlash(strings.Join(base, "/")))
+	err := filepath.WalkDir(start, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() && d.Name() == ".aliax" {
+			return filepath.SkipDir
+		}
+		if !matchSegments(segments, strings.Split(rel, "/")) {
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			files = append(files, rel)
+			return nil
+		}
+		// a matched directory, the root itself for "**", stands for its files but the cache and git ones
+		err = filepath.WalkDir(name, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == ".aliax" || d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			rel, err := filepath.Rel(root, name)
+			files = append(files, filepath.ToSlash(rel))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// matchSegments reports whether the path segments match the pattern segments,
+// "**" matching zero or more segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,161 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package runner
+
+import (
+	"aliax/internal/aos"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func write(t *testing.T, name, data string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+	assert.NoError(t, os.WriteFile(name, []byte(data), 0644))
+}
+
+func TestGlob(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"main.go", "go.mod", "cli/cmd/init.go", "cli/main.go", "dist/aliax", "dist/bin/aliax"} {
+		write(t, filepath.Join(root, name), name)
+	}
+	for pattern, want := range map[string][]string{
+		"*.go":        {"main.go"},
+		"**/*.go":     {"cli/cmd/init.go", "cli/main.go", "main.go"},
+		"cli/**/*.go": {"cli/cmd/init.go", "cli/main.go"},
+		"cli/*.go":    {"cli/main.go"},
+		"dist":        {"dist/aliax", "dist/bin/aliax"},
+		"go.mod":      {"go.mod"},
+		"missing/*":   {},
+	} {
+		files, err := glob(root, pattern)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, want, files, pattern)
+	}
+	_, err := glob(root, "[")
+	assert.Error(t, err)
+}
+
+func TestChanges(t *testing.T) {
+	prev := &fingerprint{
+		Script:  "1",
+		Sources: map[string]string{"a.go": "1", "b.go": "1"},
+	}
+	assert.Equal(t, []string{"no previous run"}, changes(nil, prev))
+	assert.Empty(t, changes(prev, prev))
+	assert.Equal(t, []string{
+		"script changed",
+		"arguments changed",
+		"source a.go changed",
+		"source c.go added",
+		"source b.go removed",
+		"generated file bin/a removed",
+	}, changes(&fingerprint{
+		Script:    "1",
+		Args:      "1",
+		Sources:   prev.Sources,
+		Generates: map[string]string{"bin/a": "1"},
+	}, &fingerprint{
+		Script:  "2",
+		Sources: map[string]string{"a.go": "2", "c.go": "1"},
+	}))
+}
+
+func TestIncremental(t *testing.T) {
+	if aos.IsWindows {
+		t.Skip("requires bash")
+	}
+	root := t.TempDir()
+	write(t, filepath.Join(root, "in.txt"), "1")
+	file := load(t, `
+script:
+  build:
+    sources: [in.txt]
+    generates: [out.txt]
+    match:
+      - run: cat in.txt >> out.txt
+`)
+	run := func(force bool) string {
+		r := New(file)
+		r.root = root
+		r.cache = loadCache(root)
+		r.Force = force
+		assert.NoError(t, r.Run(context.Background(), "build"))
+		data, _ := os.ReadFile(filepath.Join(root, "out.txt"))
+		return string(data)
+	}
+	assert.Equal(t, "1", run(false))
+	assert.Equal(t, "1", run(false))
+	write(t, filepath.Join(root, "in.txt"), "2")
+	assert.Equal(t, "12", run(false))
+	assert.Equal(t, "12", run(false))
+	assert.Equal(t, "122", run(true))
+	assert.FileExists(t, filepath.Join(root, CacheFile))
+}
+
+func TestIncrementalInputs(t *testing.T) {
+	if aos.IsWindows {
+		t.Skip("requires bash")
+	}
+	root := t.TempDir()
+	write(t, filepath.Join(root, "in.txt"), "1")
+	file := load(t, `
+script:
+  build:
+    sources: [in.txt]
+    env:
+      MODE: debug
+    match:
+      - run: echo "$MODE {{.args}}" >> out.txt
+`)
+	run := func(mode string, args ...string) string {
+		file.Script["build"].Cmd.Env["MODE"] = mode
+		r := New(file)
+		r.root = root
+		r.cache = loadCache(root)
+		r.Args = args
+		assert.NoError(t, r.Run(context.Background(), "build"))
+		data, _ := os.ReadFile(filepath.Join(root, "out.txt"))
+		return string(data)
+	}
+	assert.Equal(t, "debug [a]\n", run("debug", "a"))
+	assert.Equal(t, "debug [a]\n", run("debug", "a"))
+	assert.Equal(t, "debug [a]\ndebug [b]\n", run("debug", "b"))
+	assert.Equal(t, "debug [a]\ndebug [b]\nrelease [b]\n", run("release", "b"))
+	assert.Equal(t, "debug [a]\ndebug [b]\nrelease [b]\n", run("release", "b"))
+}
+
+func TestIncrementalRoot(t *testing.T) {
+	if aos.IsWindows {
+		t.Skip("requires bash")
+	}
+	root, out := t.TempDir(), filepath.Join(t.TempDir(), "out.txt")
+	write(t, filepath.Join(root, "in.txt"), "1")
+	write(t, filepath.Join(root, ".git", "HEAD"), "main")
+	for _, sources := range []string{`"**"`, `"**/*"`} {
+		file := load(t, fmt.Sprintf(`
+script:
+  build:
+    sources: [%s]
+    match:
+      - run: echo 1 >> %s
+`, sources, out))
+		run := func() string {
+			r := New(file)
+			r.root = root
+			r.cache = loadCache(root)
+			assert.NoError(t, r.Run(context.Background(), "build"))
+			data, _ := os.ReadFile(out)
+			return string(data)
+		}
+		os.Remove(out)
+		os.RemoveAll(filepath.Join(root, ".aliax"))
+		assert.Equal(t, "1\n", run(), sources)
+		assert.Equal(t, "1\n", run(), sources)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package runner
+
+import (
+	"aliax/internal/cfg"
+	"aliax/internal/dotenv"
+	"aliax/internal/template"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// environ returns the environment of the named script.
+func (r *Runner) environ(name string) ([]string, error) {
+	env, _, err := r.resolveEnv(name)
+	if err != nil {
+		return nil, err
+	}
+	environ := []string{}
+	for _, k := range cfg.SortedKeys(env) {
+		environ = append(environ, k+"="+env[k])
+	}
+	return environ, nil
+}
+
+// resolveEnv returns the environment of the named script, and the variables
+// of it set by the dotenv files and env maps.
+func (r *Runner) resolveEnv(name string) (env, declared map[string]string, err error) {
+	env, declared = map[string]string{}, map[string]string{}
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	if err := r.applyEnv(env, declared, r.file.Dotenv, r.file.Env, r.data(name)); err != nil {
+		return nil, nil, err
+	}
+	if sc := r.file.Script[name]; sc.Cmd != nil {
+		if err := r.applyEnv(env, declared, sc.Cmd.Dotenv, sc.Cmd.Env, r.data(name)); err != nil {
+			return nil, nil, fmt.Errorf("script %s: %w", name, err)
+		}
+	}
+	return env, declared, nil
+}
+
+// applyEnv loads the dotenv files and then vars into env and declared.
+func (r *Runner) applyEnv(env, declared map[string]string, files []string, vars map[string]string, data map[string]any) error {
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	for _, name := range files {
+		loaded, err := dotenv.ReadFile(r.path(name), lookup)
+		if err != nil {
+			return err
+		}
+		for _, v := range loaded {
+			env[v.Key] = v.Value
+			declared[v.Key] = v.Value
+		}
+	}
+	for _, k := range cfg.SortedKeys(vars) {
+		buf := &strings.Builder{}
+		if err := template.Execute(buf, vars[k], data); err != nil {
+			return fmt.Errorf("env %s: fail to execute template: %w", k, err)
+		}
+		env[k] = buf.String()
+		declared[k] = buf.String()
+	}
+	return nil
+}
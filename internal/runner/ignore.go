@@ -0,0 +1,75 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package runner
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+// ignoreRule is a pattern in the .gitignore syntax.
+type ignoreRule struct {
+	segments []string
+	// negate re-includes the paths matched by the pattern, it's set by a leading !.
+	negate bool
+	// dirOnly only matches directories, it's set by a trailing /.
+	dirOnly bool
+}
+
+// parseIgnore parses patterns in the .gitignore syntax.
+func parseIgnore(lines []string) []ignoreRule {
+	rules := []ignoreRule{}
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		line = strings.TrimPrefix(line, "/")
+		if len(line) == 0 {
+			continue
+		}
+		rule.segments = strings.Split(path.Clean(line), "/")
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// readIgnore reads the patterns of a .gitignore file, a missing file has none.
+func readIgnore(name string) []ignoreRule {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil
+	}
+	return parseIgnore(strings.Split(string(data), "\n"))
+}
+
+// ignored reports whether the slash separated path relative to the workspace
+// root is ignored by the rules, the last matching rule wins.
+func ignored(rules []ignoreRule, rel string, dir bool) bool {
+	ignore := false
+	name := strings.Split(rel, "/")
+	for _, rule := range rules {
+		if rule.dirOnly && !dir {
+			continue
+		}
+		if matchSegments(rule.segments, name) {
+			ignore = !rule.negate
+		}
+	}
+	return ignore
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+type parallelKey struct{}
+
+// withParallel marks the scripts run with ctx as running concurrently.
+func withParallel(ctx context.Context) context.Context {
+	return context.WithValue(ctx, parallelKey{}, true)
+}
+
+// outputMu serializes the lines written by concurrent scripts.
+var outputMu sync.Mutex
+
+// outputs returns the writers receiving the output of the named script,
+// prefixed with its name when it runs concurrently with other scripts.
+func outputs(ctx context.Context, name string) (stdout, stderr *prefixWriter) {
+	prefix := ""
+	if ctx.Value(parallelKey{}) != nil {
+		prefix = "[" + name + "] "
+	}
+	return &prefixWriter{w: os.Stdout, prefix: prefix}, &prefixWriter{w: os.Stderr, prefix: prefix}
+}
+
+// prefixWriter writes every line it receives to w after prefix.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	if len(p.prefix) == 0 {
+		return p.w.Write(b)
+	}
+	p.buf = append(p.buf, b...)
+	i := bytes.LastIndexByte(p.buf, '\n')
+	if i < 0 {
+		return len(b), nil
+	}
+	if err := p.write(p.buf[:i+1]); err != nil {
+		return 0, err
+	}
+	p.buf = append(p.buf[:0], p.buf[i+1:]...)
+	return len(b), nil
+}
+
+// Flush writes the buffered incomplete line, if any.
+func (p *prefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	err := p.write(append(p.buf, '\n'))
+	p.buf = p.buf[:0]
+	return err
+}
+
+func (p *prefixWriter) write(lines []byte) error {
+	out := []byte{}
+	for _, line := range bytes.SplitAfter(lines, []byte("\n")) {
+		if len(line) > 0 {
+			out = append(append(out, p.prefix...), line...)
+		}
+	}
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	_, err := p.w.Write(out)
+	return err
+}
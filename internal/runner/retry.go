@@ -0,0 +1,113 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package runner
+
+import (
+	"aliax/internal/cfg"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/caarlos0/log"
+)
+
+// retryPolicy describes how the command of a script is attempted.
+type retryPolicy struct {
+	// timeout bounds every attempt, zero means no limit.
+	timeout time.Duration
+	retries int
+	// delay is waited before the first retry and doubled after every failed retry.
+	delay time.Duration
+}
+
+// policyOf returns the policy of the script, the options set by the match case take precedence.
+func policyOf(sc cfg.Script, c *cfg.Case) (retryPolicy, error) {
+	timeout, retries, delay := sc.Timeout, sc.Retries, sc.RetryDelay
+	if c != nil {
+		if len(c.Timeout) > 0 {
+			timeout = c.Timeout
+		}
+		if c.Retries != nil {
+			retries = *c.Retries
+		}
+		if len(c.RetryDelay) > 0 {
+			delay = c.RetryDelay
+		}
+	}
+	p := retryPolicy{retries: retries}
+	var err error
+	if len(timeout) > 0 {
+		if p.timeout, err = time.ParseDuration(timeout); err != nil {
+			return p, fmt.Errorf("invalid timeout: %w", err)
+		}
+	}
+	if len(delay) > 0 {
+		if p.delay, err = time.ParseDuration(delay); err != nil {
+			return p, fmt.Errorf("invalid retry delay: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// attempt runs the command until it succeeds or the retries are exhausted.
+func attempt(ctx context.Context, name string, p retryPolicy, run func(context.Context) error) error {
+	delay := p.delay
+	for i := 0; ; i++ {
+		runCtx, cancel := ctx, context.CancelFunc(func() {})
+		if p.timeout > 0 {
+			runCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		}
+		start := time.Now()
+		err := run(runCtx)
+		if ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", p.timeout)
+		}
+		cancel()
+
+		entry := log.WithField("attempt", fmt.Sprintf("%d/%d", i+1, p.retries+1)).
+			WithField("duration", time.Since(start).Round(time.Millisecond)).
+			WithField("exit code", exitCode(err))
+		if err != nil {
+			entry = entry.WithError(err)
+		}
+		switch {
+		case err == nil && (p.timeout > 0 || p.retries > 0):
+			entry.Infof("%s succeeded", name)
+		case err == nil:
+			entry.Debugf("%s succeeded", name)
+		case ctx.Err() != nil:
+			return err
+		case i == p.retries:
+			entry.Warnf("%s failed", name)
+			return err
+		default:
+			entry.Warnf("%s failed, retrying in %s", name, delay)
+		}
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// exitCode returns the exit code of the command which returned err,
+// or -1 when it didn't exit on its own.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,494 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package runner
+
+import (
+	"aliax/internal/cfg"
+	"aliax/internal/shell"
+	"aliax/internal/template"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caarlos0/log"
+	"github.com/google/shlex"
+)
+
+// Runner executes the scripts of a configuration. Dependencies declared with
+// deps run first, in topological order, followed by the scripts declared with
+// parallel, which run concurrently. Each script runs at most once for the
+// lifetime of the runner.
+type Runner struct {
+	file *cfg.Aliax
+	// root is the directory the scripts run in.
+	root string
+	// Dry only logs the commands instead of running them.
+	Dry bool
+	// Jobs limits the number of scripts running at the same time,
+	// the number of CPUs is used when it's not positive.
+	Jobs int
+	// Force runs the scripts declaring sources or generates
+	// even when they're up to date.
+	Force bool
+	// Why explains at the info level why scripts declaring sources
+	// or generates run or are skipped.
+	Why bool
+	// Args are the arguments passed to the script given to Run, they're
+	// exposed to its templates as args and argsQuoted. Its dependencies
+	// don't receive them.
+	Args []string
+	// Interval is how often Watch polls the watched files
+	// and Debounce how long they must be stable before a run,
+	// defaults are used when they're not positive.
+	Interval time.Duration
+	Debounce time.Duration
+
+	target string
+	mu     sync.Mutex
+	tasks  map[string]*task
+	sem    chan struct{}
+	cache  *cache
+	init   bool
+}
+
+// task is a script started by the runner, done is closed once it's finished.
+type task struct {
+	done chan struct{}
+	err  error
+}
+
+// New returns a runner for the scripts of file.
+func New(file *cfg.Aliax) *Runner {
+	root := cfg.Root()
+	return &Runner{
+		file:  file,
+		root:  root,
+		tasks: map[string]*task{},
+		cache: loadCache(root),
+	}
+}
+
+// Plan returns the scripts to run for name, its dependencies coming first.
+// It fails on unknown scripts and on dependency cycles, the error showing the
+// path of the cycle, e.g. "dependency cycle: release -> build -> release".
+func (r *Runner) Plan(name string) ([]string, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := map[string]int{}
+	stack := []string{}
+	order := []string{}
+
+	var visit func(name, from string) error
+	visit = func(name, from string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			for i, s := range stack {
+				if s == name {
+					cycle := append(append([]string{}, stack[i:]...), name)
+					return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+				}
+			}
+		}
+		sc, ok := r.file.Script[name]
+		if !ok {
+			if len(from) > 0 {
+				return fmt.Errorf("script %q depends on unknown script %q", from, name)
+			}
+			return fmt.Errorf("unknown script %q", name)
+		}
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range append(append([]string{}, sc.Deps...), sc.Parallel...) {
+			if err := visit(dep, name); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	if err := visit(name, ""); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// Run runs the named script after its dependencies.
+// Scripts which already ran are skipped.
+func (r *Runner) Run(ctx context.Context, name string) error {
+	plan, err := r.Plan(name)
+	if err != nil {
+		return err
+	}
+	if len(plan) > 1 {
+		log.WithField("order", strings.Join(plan, " -> ")).Debugf("resolved dependencies of %s", name)
+	}
+	if err := r.initVariables(); err != nil {
+		return err
+	}
+	r.target = name
+	return r.ensure(ctx, name)
+}
+
+// RunParallel runs the named scripts concurrently, each after its dependencies.
+// The output of every script is prefixed with its name. When a script fails,
+// the others are cancelled and the error lists every failed script.
+func (r *Runner) RunParallel(ctx context.Context, names []string) error {
+	for _, name := range names {
+		if _, err := r.Plan(name); err != nil {
+			return err
+		}
+	}
+	if err := r.initVariables(); err != nil {
+		return err
+	}
+	return r.parallel(ctx, names)
+}
+
+// ensure runs the named script unless it was already started,
+// in which case it waits for it and returns its result.
+func (r *Runner) ensure(ctx context.Context, name string) error {
+	r.mu.Lock()
+	t, ok := r.tasks[name]
+	if !ok {
+		t = &task{done: make(chan struct{})}
+		r.tasks[name] = t
+	}
+	r.mu.Unlock()
+
+	if ok {
+		select {
+		case <-t.done:
+			return t.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	t.err = r.runTask(ctx, name)
+	close(t.done)
+	return t.err
+}
+
+// runTask runs the dependencies of the named script, then its parallel
+// scripts and finally the script itself.
+func (r *Runner) runTask(ctx context.Context, name string) error {
+	sc := r.file.Script[name]
+	if len(sc.When) > 0 {
+		env, err := r.environ(name)
+		if err != nil {
+			return err
+		}
+		ok, err := holds(sc.When, r.facts(env))
+		if err != nil {
+			return fmt.Errorf("script %s: %w", name, err)
+		}
+		if !ok {
+			log.WithField("when", sc.When).Infof("skipping %s, its condition doesn't hold", name)
+			return nil
+		}
+	}
+	for _, dep := range sc.Deps {
+		if err := r.ensure(ctx, dep); err != nil {
+			return fmt.Errorf("dependency %s: %w", dep, err)
+		}
+	}
+	if len(sc.Parallel) > 0 {
+		if err := r.parallel(ctx, sc.Parallel); err != nil {
+			return err
+		}
+	}
+	incremental := len(sc.Sources) > 0 || len(sc.Generates) > 0
+	if incremental && !r.Force {
+		ok, err := r.upToDate(name)
+		if err != nil || ok {
+			return err
+		}
+	}
+	if err := r.acquire(ctx); err != nil {
+		return err
+	}
+	defer r.release()
+	if err := r.runScript(ctx, name); err != nil {
+		return err
+	}
+	if incremental && !r.Dry {
+		fp, err := r.fingerprint(name)
+		if err != nil {
+			return fmt.Errorf("fingerprinting %s: %w", name, err)
+		}
+		if err = r.cache.set(name, fp); err != nil {
+			return fmt.Errorf("writing %s: %w", CacheFile, err)
+		}
+	}
+	return nil
+}
+
+// upToDate reports whether none of the sources and generated files
+// of the named script changed since its last successful run.
+func (r *Runner) upToDate(name string) (bool, error) {
+	fp, err := r.fingerprint(name)
+	if err != nil {
+		return false, fmt.Errorf("fingerprinting %s: %w", name, err)
+	}
+	reasons := changes(r.cache.get(name), fp)
+	entry := log.WithField("script", name)
+	if len(reasons) == 0 {
+		if r.Why {
+			entry.Info("up to date, no source or generated file changed")
+		} else {
+			entry.Info("up to date")
+		}
+		return true, nil
+	}
+	entry = entry.WithField("reason", strings.Join(reasons, "\n"))
+	if r.Why {
+		entry.Info("out of date")
+	} else {
+		entry.Debug("out of date")
+	}
+	return false, nil
+}
+
+// fingerprint computes the current fingerprint of the named script,
+// its arguments and declared variables included.
+func (r *Runner) fingerprint(name string) (*fingerprint, error) {
+	_, env, err := r.resolveEnv(name)
+	if err != nil {
+		return nil, err
+	}
+	return fingerprintOf(r.root, r.file.Script[name], r.args(name), env)
+}
+
+// parallel runs the named scripts concurrently and cancels the remaining
+// ones as soon as one of them fails.
+func (r *Runner) parallel(ctx context.Context, names []string) error {
+	ctx, cancel := context.WithCancel(withParallel(ctx))
+	defer cancel()
+
+	log.WithField("jobs", r.jobs()).Debugf("running %s in parallel", strings.Join(names, ", "))
+	errs := make([]error, len(names))
+	wg := sync.WaitGroup{}
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.ensure(ctx, name); err != nil {
+				errs[i] = err
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	failed := []error{}
+	for i, err := range errs {
+		switch {
+		case err == nil:
+		case errors.Is(err, context.Canceled):
+			log.WithField("job", names[i]).Warn("cancelled")
+		default:
+			failed = append(failed, fmt.Errorf("%s: %w", names[i], err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d jobs failed:\n%w", len(failed), len(names), errors.Join(failed...))
+	}
+	// the parent context was cancelled, e.g. by an interrupt
+	return ctx.Err()
+}
+
+func (r *Runner) jobs() int {
+	if r.Jobs > 0 {
+		return r.Jobs
+	}
+	return runtime.NumCPU()
+}
+
+// acquire waits for a free job slot, scripts only hold a slot while their
+// own command runs so that waiting on dependencies can't exhaust the slots.
+func (r *Runner) acquire(ctx context.Context) error {
+	r.mu.Lock()
+	if r.sem == nil {
+		r.sem = make(chan struct{}, r.jobs())
+	}
+	sem := r.sem
+	r.mu.Unlock()
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Runner) release() {
+	<-r.sem
+}
+
+// initVariables renders the templates of the variables once,
+// before any script starts.
+func (r *Runner) initVariables() error {
+	if r.init {
+		return nil
+	}
+	r.init = true
+	log.Debugf("initializing variables")
+	log.IncreasePadding()
+	defer log.DecreasePadding()
+	for k, v := range r.file.Variable {
+		buf := &strings.Builder{}
+		log.Debugf("initializing %s", k)
+		if err := template.Execute(buf, v, nil); err != nil {
+			return fmt.Errorf("fail to execute template: %w", err)
+		}
+		r.file.Variable[k] = buf.String()
+	}
+	return nil
+}
+
+func (r *Runner) runScript(ctx context.Context, name string) error {
+	stdout, stderr := outputs(ctx, name)
+	defer stdout.Flush()
+	defer stderr.Flush()
+	env, err := r.environ(name)
+	if err != nil {
+		return err
+	}
+	script := r.file.Script[name]
+	p := &process{dir: r.path(script.Dir), env: env, stdout: stdout, stderr: stderr}
+	run := ""
+	var c *cfg.Case
+	switch {
+	case script.Run != nil:
+		run = *script.Run
+	case script.Cmd != nil && len(script.Cmd.Match) > 0:
+		// TODO map collect
+		if c, err = selectCase(script.Cmd.Match, r.facts(env)); err != nil {
+			return fmt.Errorf("script %s: %w", name, err)
+		}
+		if c == nil {
+			log.Infof("skipping %s, none of its cases applies", name)
+			return nil
+		}
+		if len(c.Dir) > 0 {
+			p.dir = r.path(c.Dir)
+		}
+		run = c.Run
+	default:
+		return nil
+	}
+	policy, err := policyOf(script, c)
+	if err != nil {
+		return err
+	}
+
+	buf := &strings.Builder{}
+	if err := template.Execute(buf, run, r.data(name)); err != nil {
+		return fmt.Errorf("fail to execute template: %w", err)
+	}
+	log.WithField("script", buf.String()).Infof("running command: %s", name)
+	if r.Dry {
+		return nil
+	}
+	return attempt(ctx, name, policy, func(ctx context.Context) error {
+		return execute(ctx, p, buf.String())
+	})
+}
+
+// data returns what the templates of the named script are rendered with:
+// the variables, args and argsQuoted.
+func (r *Runner) data(name string) map[string]any {
+	data := map[string]any{}
+	for k, v := range r.file.Variable {
+		data[k] = v
+	}
+	args := r.args(name)
+	quoted := []string{}
+	for _, arg := range args {
+		quoted = append(quoted, shell.Quote(arg))
+	}
+	data["args"] = args
+	data["argsQuoted"] = strings.Join(quoted, " ")
+	return data
+}
+
+// args returns the arguments of the named script, only the target receives them.
+func (r *Runner) args(name string) []string {
+	args := []string{}
+	if name == r.target {
+		args = append(args, r.Args...)
+	}
+	return args
+}
+
+// path resolves name against the workspace root.
+func (r *Runner) path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(r.root, name)
+}
+
+// process describes how the commands of a script are started.
+type process struct {
+	dir    string
+	env    []string
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (p *process) setup(cmd *exec.Cmd) {
+	cmd.Dir = p.dir
+	cmd.Env = p.env
+	cmd.Stdout = p.stdout
+	cmd.Stderr = p.stderr
+}
+
+func execute(ctx context.Context, p *process, cmdStr string) error {
+	if strings.Contains(cmdStr, "\n") {
+		if err := shell.OnceScriptContext(ctx, p.dir, cmdStr, p.setup); err != nil {
+			return cancelled(ctx, err)
+		}
+		return nil
+	}
+	return executeCommand(ctx, p, cmdStr)
+}
+
+func executeCommand(ctx context.Context, p *process, cmdStr string) error {
+	// the command is only split to report unbalanced quotes,
+	// the shell receives it unchanged so that quoted arguments are kept.
+	if _, err := shlex.Split(cmdStr); err != nil {
+		return fmt.Errorf("error splitting command: %v", err)
+	}
+
+	cmdExec := shell.StartCmdContext(ctx, cmdStr)
+	p.setup(cmdExec)
+
+	if err := cmdExec.Run(); err != nil {
+		return cancelled(ctx, fmt.Errorf("error executing command: %w", err))
+	}
+
+	return nil
+}
+
+// cancelled returns the error of the context when it's done, the command
+// failed because it was killed rather than on its own.
+func cancelled(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
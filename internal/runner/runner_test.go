@@ -0,0 +1,305 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package runner
+
+import (
+	"aliax/internal/aos"
+	"aliax/internal/cfg"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func load(t *testing.T, data string) *cfg.Aliax {
+	var file cfg.Aliax
+	assert.NoError(t, yaml.Unmarshal([]byte(data), &file))
+	return &file
+}
+
+func TestPlan(t *testing.T) {
+	r := New(load(t, `
+script:
+  build: go build
+  test: go test
+  release:
+    deps: [build, test]
+  dev-deploy:
+    deps: [release, build]
+`))
+	plan, err := r.Plan("dev-deploy")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build", "test", "release", "dev-deploy"}, plan)
+
+	_, err = r.Plan("missing")
+	assert.EqualError(t, err, `unknown script "missing"`)
+}
+
+func TestPlanCycle(t *testing.T) {
+	r := New(load(t, `
+script:
+  a:
+    deps: [b]
+  b:
+    deps: [c]
+  c:
+    deps: [b]
+  d:
+    deps: [e]
+`))
+	_, err := r.Plan("a")
+	assert.EqualError(t, err, "dependency cycle: b -> c -> b")
+
+	_, err = r.Plan("d")
+	assert.EqualError(t, err, `script "d" depends on unknown script "e"`)
+}
+
+func TestRunOnce(t *testing.T) {
+	r := New(load(t, `
+script:
+  build: go build
+  release:
+    deps: [build]
+`))
+	r.Dry = true
+	assert.NoError(t, r.Run(context.Background(), "release"))
+	assert.Contains(t, r.tasks, "build")
+	assert.Contains(t, r.tasks, "release")
+}
+
+func TestPlanParallel(t *testing.T) {
+	r := New(load(t, `
+script:
+  gen: go generate
+  lint: golangci-lint run
+  test:
+    deps: [gen]
+  ci:
+    deps: [gen]
+    parallel: [lint, test]
+  loop:
+    parallel: [loop]
+`))
+	plan, err := r.Plan("ci")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gen", "lint", "test", "ci"}, plan)
+
+	_, err = r.Plan("loop")
+	assert.EqualError(t, err, "dependency cycle: loop -> loop")
+}
+
+func TestRunParallel(t *testing.T) {
+	r := New(load(t, `
+script:
+  gen: go generate
+  lint: golangci-lint run
+  test:
+    deps: [gen]
+  ci:
+    deps: [gen]
+    parallel: [lint, test]
+`))
+	r.Dry = true
+	r.Jobs = 1
+	assert.NoError(t, r.RunParallel(context.Background(), []string{"ci", "test"}))
+	for _, name := range []string{"gen", "lint", "test", "ci"} {
+		assert.Contains(t, r.tasks, name)
+	}
+}
+
+func TestParallelFailure(t *testing.T) {
+	if aos.IsWindows {
+		t.Skip("requires bash")
+	}
+	r := New(load(t, `
+script:
+  fail: exit 3
+  slow: sleep 10
+`))
+	r.Jobs = 2
+	start := time.Now()
+	err := r.RunParallel(context.Background(), []string{"fail", "slow"})
+	assert.ErrorContains(t, err, "1 of 2 jobs failed")
+	assert.ErrorContains(t, err, "fail: error executing command: exit status 3")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestPrefixWriter(t *testing.T) {
+	buf := &strings.Builder{}
+	w := &prefixWriter{w: buf, prefix: "[lint] "}
+	w.Write([]byte("a\nb"))
+	w.Write([]byte("c\nd"))
+	assert.Equal(t, "[lint] a\n[lint] bc\n", buf.String())
+	w.Flush()
+	assert.Equal(t, "[lint] a\n[lint] bc\n[lint] d\n", buf.String())
+}
+
+func TestEnviron(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(root, ".env"), []byte("export A=dotenv\nB=${A}-b\n"), 0644))
+	t.Setenv("ALIAX_TEST", "process")
+	r := New(load(t, `
+variable:
+  Output: dist
+env:
+  A: top
+  OUT: "{{.Output}}/bin"
+dotenv: [.env]
+script:
+  build:
+    dotenv: [.env]
+    env:
+      OUT: script
+`))
+	r.root = root
+	env, err := r.environ("build")
+	assert.NoError(t, err)
+	assert.Contains(t, env, "ALIAX_TEST=process")
+	assert.Contains(t, env, "A=dotenv")
+	assert.Contains(t, env, "B=dotenv-b")
+	assert.Contains(t, env, "OUT=script")
+
+	r.file.Script["build"].Cmd.Dotenv = []string{"missing.env"}
+	_, err = r.environ("build")
+	assert.ErrorContains(t, err, "script build: ")
+}
+
+func TestRunDir(t *testing.T) {
+	if aos.IsWindows {
+		t.Skip("the scripts use bash")
+	}
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "sub"), 0755))
+	r := New(load(t, `
+script:
+  script-dir:
+    dir: sub
+    match:
+      - run: pwd > out
+  case-dir:
+    dir: sub
+    match:
+      - run: pwd > out
+        dir: .
+`))
+	r.root = root
+	assert.NoError(t, r.Run(context.Background(), "script-dir"))
+	assert.FileExists(t, filepath.Join(root, "sub", "out"))
+	assert.NoError(t, r.Run(context.Background(), "case-dir"))
+	assert.FileExists(t, filepath.Join(root, "out"))
+}
+
+func TestRunArgs(t *testing.T) {
+	if aos.IsWindows {
+		t.Skip("the scripts use bash")
+	}
+	root := t.TempDir()
+	r := New(load(t, `
+variable:
+  Output: dist
+script:
+  prepare: "printf '%s|' {{.Output}} {{len .args}} > prepare.out"
+  build:
+    deps: [prepare]
+    match:
+      - run: "printf '%s|' {{.Output}} {{index .args 0}} {{.argsQuoted}} > build.out"
+`))
+	r.root = root
+	r.Args = []string{"a", "b c", "it's", "$HOME"}
+	assert.NoError(t, r.Run(context.Background(), "build"))
+	out, err := os.ReadFile(filepath.Join(root, "build.out"))
+	assert.NoError(t, err)
+	assert.Equal(t, "dist|a|a|b c|it's|$HOME|", string(out))
+	out, err = os.ReadFile(filepath.Join(root, "prepare.out"))
+	assert.NoError(t, err)
+	assert.Equal(t, "dist|0|", string(out))
+}
+
+func TestRetry(t *testing.T) {
+	if aos.IsWindows {
+		t.Skip("the scripts use bash")
+	}
+	root := t.TempDir()
+	r := New(load(t, `
+script:
+  flaky:
+    retries: 1
+    match:
+      - run: |
+          echo run >> runs
+          test $(wc -l < runs) -ge 2
+        retries: 2
+        retry_delay: 10ms
+  hang:
+    timeout: 100ms
+    match:
+      - run: sleep 10
+`))
+	r.root = root
+	assert.NoError(t, r.Run(context.Background(), "flaky"))
+	data, err := os.ReadFile(filepath.Join(root, "runs"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "run"))
+
+	start := time.Now()
+	err = r.Run(context.Background(), "hang")
+	assert.EqualError(t, err, "timed out after 100ms")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestPolicyOf(t *testing.T) {
+	retries := 3
+	p, err := policyOf(cfg.Script{Timeout: "1m", Retries: 1, RetryDelay: "1s"}, &cfg.Case{Retries: &retries})
+	assert.NoError(t, err)
+	assert.Equal(t, retryPolicy{timeout: time.Minute, retries: 3, delay: time.Second}, p)
+
+	p, err = policyOf(cfg.Script{Retries: 1}, &cfg.Case{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, p.retries)
+	retries = 0
+	p, err = policyOf(cfg.Script{Retries: 1}, &cfg.Case{Retries: &retries})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, p.retries)
+
+	_, err = policyOf(cfg.Script{}, &cfg.Case{Timeout: "soon"})
+	assert.ErrorContains(t, err, "invalid timeout")
+}
+
+func TestWhen(t *testing.T) {
+	if aos.IsWindows {
+		t.Skip("the scripts use bash")
+	}
+	root := t.TempDir()
+	r := New(load(t, `
+script:
+  skipped:
+    when: not exists(go.mod)
+    match:
+      - run: touch skipped.out
+  build:
+    env:
+      MODE: release
+    match:
+      - run: echo windows > build.out
+        when: os == windows
+      - run: echo powershell > build.out
+        platform: powershell
+      - run: echo release > build.out
+        when: env.MODE == release and exists(go.mod)
+      - run: echo default > build.out
+`))
+	r.root = root
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), nil, 0644))
+	assert.NoError(t, r.Run(context.Background(), "skipped"))
+	assert.NoFileExists(t, filepath.Join(root, "skipped.out"))
+	assert.NoError(t, r.Run(context.Background(), "build"))
+	out, err := os.ReadFile(filepath.Join(root, "build.out"))
+	assert.NoError(t, err)
+	assert.Equal(t, "release\n", string(out))
+}
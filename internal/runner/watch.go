@@ -0,0 +1,267 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package runner
+
+import (
+	"aliax/internal/cfg"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/log"
+)
+
+const (
+	defaultInterval = 500 * time.Millisecond
+	defaultDebounce = 200 * time.Millisecond
+)
+
+// Watch runs the named script, then runs it again along with its dependencies
+// whenever one of the files it watches changes, until ctx is done. The files
+// are polled, and a burst of changes triggers a single run once the files are
+// stable. The previous run is cancelled first, which terminates its processes.
+func (r *Runner) Watch(ctx context.Context, name string) error {
+	sc, ok := r.file.Script[name]
+	if !ok {
+		return fmt.Errorf("unknown script: %s", name)
+	}
+	w := r.watcher(sc)
+	prev, err := w.snapshot()
+	if err != nil {
+		return err
+	}
+	log.WithField("files", len(prev)).Infof("watching %s", name)
+
+	var (
+		cancel context.CancelFunc
+		done   chan error
+	)
+	start := func() {
+		var runCtx context.Context
+		runCtx, cancel = context.WithCancel(ctx)
+		done = make(chan error, 1)
+		r.reset()
+		go func() { done <- r.Run(runCtx, name) }()
+	}
+	stop := func() {
+		if done == nil {
+			return
+		}
+		cancel()
+		<-done
+		done = nil
+	}
+
+	ticker := time.NewTicker(r.interval())
+	defer ticker.Stop()
+	start()
+	for {
+		select {
+		case <-ctx.Done():
+			stop()
+			return nil
+		case err := <-done:
+			done = nil
+			cancel()
+			if err != nil {
+				log.WithError(err).Errorf("running script: %s", name)
+			}
+			log.Info("waiting for changes")
+		case <-ticker.C:
+			cur, err := w.snapshot()
+			if err != nil {
+				stop()
+				return err
+			}
+			if len(diffSnapshots(prev, cur)) == 0 {
+				continue
+			}
+			if cur, err = w.settle(ctx, cur); err != nil {
+				stop()
+				return err
+			}
+			if ctx.Err() != nil {
+				continue
+			}
+			changed := diffSnapshots(prev, cur)
+			prev = cur
+			if len(changed) == 0 {
+				continue
+			}
+			log.WithField("files", strings.Join(changed, ", ")).Infof("restarting %s", name)
+			stop()
+			start()
+		}
+	}
+}
+
+// reset forgets the scripts which already ran, so that they run again.
+func (r *Runner) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tasks = map[string]*task{}
+}
+
+func (r *Runner) interval() time.Duration {
+	if r.Interval > 0 {
+		return r.Interval
+	}
+	return defaultInterval
+}
+
+func (r *Runner) debounce() time.Duration {
+	if r.Debounce > 0 {
+		return r.Debounce
+	}
+	return defaultDebounce
+}
+
+// watcher polls the files watched by a script.
+type watcher struct {
+	root     string
+	debounce time.Duration
+	// patterns are the watched glob patterns split in segments, the sources
+	// of the script or the whole workspace when it doesn't declare any.
+	patterns [][]string
+	// generates are the files written by the script, they never trigger a run.
+	generates [][]string
+	// rules are the patterns of the .gitignore of the workspace root
+	// followed by the ignore list of the script.
+	rules []ignoreRule
+}
+
+func (r *Runner) watcher(sc cfg.Script) *watcher {
+	patterns := sc.Watch
+	if len(patterns) == 0 {
+		patterns = sc.Sources
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"**"}
+	}
+	rules := readIgnore(filepath.Join(r.root, ".gitignore"))
+	return &watcher{
+		root:      r.root,
+		debounce:  r.debounce(),
+		patterns:  splitPatterns(patterns),
+		generates: splitPatterns(sc.Generates),
+		rules:     append(rules, parseIgnore(sc.Ignore)...),
+	}
+}
+
+func splitPatterns(patterns []string) [][]string {
+	split := [][]string{}
+	for _, p := range patterns {
+		split = append(split, strings.Split(path.Clean(filepath.ToSlash(p)), "/"))
+	}
+	return split
+}
+
+// fileState is what tells a file changed between two polls.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshot returns the state of the watched files keyed by their slash
+// separated path relative to the workspace root.
+func (w *watcher) snapshot() (map[string]fileState, error) {
+	files := map[string]fileState{}
+	err := filepath.WalkDir(w.root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// the file was removed while walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if name == w.root {
+			return nil
+		}
+		rel, err := filepath.Rel(w.root, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" || d.Name() == ".aliax" || ignored(w.rules, rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// the temporary files running multiline scripts
+		if strings.HasPrefix(d.Name(), "aliax_temp_") {
+			return nil
+		}
+		if ignored(w.rules, rel, false) || !matchAny(w.patterns, rel) || matchAny(w.generates, rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		files[rel] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return files, err
+}
+
+// settle polls the files until they're unchanged for the debounce delay
+// and returns their last state.
+func (w *watcher) settle(ctx context.Context, cur map[string]fileState) (map[string]fileState, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return cur, nil
+		case <-time.After(w.debounce):
+		}
+		next, err := w.snapshot()
+		if err != nil {
+			return nil, err
+		}
+		if len(diffSnapshots(cur, next)) == 0 {
+			return next, nil
+		}
+		cur = next
+	}
+}
+
+// matchAny reports whether one of the patterns matches the slash separated
+// path or one of its directories.
+func matchAny(patterns [][]string, rel string) bool {
+	name := strings.Split(rel, "/")
+	for _, p := range patterns {
+		for i := 1; i <= len(name); i++ {
+			if matchSegments(p, name[:i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// diffSnapshots returns the sorted paths of the files added, changed or removed.
+func diffSnapshots(prev, cur map[string]fileState) []string {
+	changed := []string{}
+	for name, state := range cur {
+		if old, ok := prev[name]; !ok || old.size != state.size || !old.modTime.Equal(state.modTime) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := cur[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,114 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package runner
+
+import (
+	"aliax/internal/aos"
+	"aliax/internal/cfg"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnore(t *testing.T) {
+	rules := parseIgnore([]string{
+		"# comment",
+		"*.log",
+		"!keep.log",
+		"dist/",
+		"/root.txt",
+		"docs/*.md",
+	})
+	for rel, want := range map[string]bool{
+		"a.log":         true,
+		"sub/b.log":     true,
+		"keep.log":      false,
+		"dist":          true,
+		"root.txt":      true,
+		"sub/root.txt":  false,
+		"docs/a.md":     true,
+		"docs/sub/a.md": false,
+		"main.go":       false,
+	} {
+		assert.Equal(t, want, ignored(rules, rel, rel == "dist"), rel)
+	}
+	// dist/ only matches directories
+	assert.False(t, ignored(rules, "dist", false))
+}
+
+func TestSnapshot(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"src/a.go", "src/a.tmp", "src/gen/out.go", "dist/app", "main.go"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, name), nil, 0644))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("dist/\n"), 0644))
+
+	r := New(load(t, `
+script:
+  build:
+    watch: [src, dist]
+    ignore: ["*.tmp"]
+    generates: [src/gen]
+    match:
+      - run: go build
+  all:
+    match:
+      - run: go build
+`))
+	r.root = root
+	files, err := r.watcher(r.file.Script["build"]).snapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"src/a.go"}, cfg.SortedKeys(toStrings(files)))
+
+	files, err = r.watcher(r.file.Script["all"]).snapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".gitignore", "main.go", "src/a.go", "src/a.tmp", "src/gen/out.go"}, cfg.SortedKeys(toStrings(files)))
+}
+
+func toStrings(files map[string]fileState) map[string]string {
+	m := map[string]string{}
+	for k := range files {
+		m[k] = ""
+	}
+	return m
+}
+
+func TestWatch(t *testing.T) {
+	if aos.IsWindows {
+		t.Skip("the scripts use bash")
+	}
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "src"), 0755))
+	r := New(load(t, `
+script:
+  build:
+    watch: [src]
+    match:
+      - run: echo run >> runs
+`))
+	r.root = root
+	r.Interval = 10 * time.Millisecond
+	r.Debounce = 10 * time.Millisecond
+
+	runs := func() int {
+		data, _ := os.ReadFile(filepath.Join(root, "runs"))
+		return strings.Count(string(data), "run")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Watch(ctx, "build") }()
+
+	assert.Eventually(t, func() bool { return runs() == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "src", "a.go"), []byte("package a"), 0644))
+	assert.Eventually(t, func() bool { return runs() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package runner
+
+import (
+	"aliax/internal/aos"
+	"aliax/internal/cfg"
+	"aliax/internal/cond"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// facts returns what the conditions of a script are evaluated against.
+func (r *Runner) facts(env []string) *cond.Facts {
+	vars := map[string]string{}
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			vars[k] = v
+		}
+	}
+	shell := "bash"
+	if aos.IsWindows {
+		shell = "powershell"
+	}
+	return &cond.Facts{
+		OS:    runtime.GOOS,
+		Arch:  runtime.GOARCH,
+		Shell: shell,
+		LookupEnv: func(name string) (string, bool) {
+			v, ok := vars[name]
+			return v, ok
+		},
+		Exists: func(name string) bool {
+			_, err := os.Stat(r.path(name))
+			return err == nil
+		},
+	}
+}
+
+// holds reports whether the condition holds, an empty condition always does.
+func holds(when string, facts *cond.Facts) (bool, error) {
+	if len(when) == 0 {
+		return true, nil
+	}
+	e, err := cond.Parse(when)
+	if err != nil {
+		return false, fmt.Errorf("invalid condition %q: %w", when, err)
+	}
+	return cond.Eval(e, facts), nil
+}
+
+// selectCase returns the first case whose platform and condition hold,
+// or nil when there is none.
+func selectCase(cases []cfg.Case, facts *cond.Facts) (*cfg.Case, error) {
+	for i := range cases {
+		c := &cases[i]
+		if !cond.Eval(cond.Platform(c.Platform), facts) {
+			continue
+		}
+		ok, err := holds(c.When, facts)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
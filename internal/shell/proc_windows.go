@@ -0,0 +1,16 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package shell
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// killGroup makes the cancellation of the command kill its process tree.
+func killGroup(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+	}
+}
@@ -6,21 +6,32 @@ package shell
 import (
 	"aliax/internal/aos"
 	"aliax/internal/text"
-	"fmt"
+	"context"
 	"os"
 	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/log"
 )
 
-// StartCmd prepares a command for execution. It handles Windows and Unix-based OSes separately.
-// On Windows, it runs the command through `cmd` with `/C`.
-// On Unix-based OSes (Linux/macOS), it runs the command through `bash` with the `-c` option.
-func StartCmd(name string, arg ...string) *exec.Cmd {
+// waitDelay is how long a cancelled command is given to exit
+// before it's killed and its output pipes are closed.
+const waitDelay = 5 * time.Second
+
+// StartCmdContext prepares a command for execution. On Windows, it runs the command
+// through `cmd` with `/C`, and through `bash` with the `-c` option otherwise.
+// The command and the processes it spawns are terminated once the context is done.
+func StartCmdContext(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	var cmd *exec.Cmd
 	if aos.IsWindows {
-		return exec.Command("cmd", append([]string{"/C", name}, arg...)...)
+		cmd = exec.CommandContext(ctx, "cmd", append([]string{"/C", name}, arg...)...)
+	} else {
+		cmd = exec.CommandContext(ctx, "bash", append([]string{"-c", name}, arg...)...)
 	}
-	return exec.Command("bash", append([]string{"-c", name}, arg...)...)
+	killGroup(cmd)
+	cmd.WaitDelay = waitDelay
+	return cmd
 }
 
 // Run executes a command and captures its output (both stdout and stderr).
@@ -46,14 +57,16 @@ func Run(name string, arg ...string) error {
 	return nil
 }
 
-// OnceScript creates a temporary script file, writes the provided script content to it,
-// and then executes it once based on the operating system.
-func OnceScript(s string) error {
+// OnceScriptContext writes the script to a temporary file and executes it once in dir
+// based on the operating system. setup customizes the command running the script
+// before it starts, e.g. to redirect its output or to set its environment.
+// The script and the processes it spawns are terminated once the context is done.
+func OnceScriptContext(ctx context.Context, dir, s string, setup func(*exec.Cmd)) error {
 	suffix := ".sh"
 	if aos.IsWindows {
 		suffix = ".ps1"
 	}
-	tmpFile, err := os.CreateTemp(".", fmt.Sprintf("aliax_temp_*.%s", suffix))
+	tmpFile, err := os.CreateTemp("", "aliax_temp_*"+suffix)
 	if err != nil {
 		return err
 	}
@@ -68,13 +81,17 @@ func OnceScript(s string) error {
 
 	var cmd *exec.Cmd
 	if aos.IsWindows {
-		cmd = exec.Command("powershell", tmpFile.Name())
+		cmd = exec.CommandContext(ctx, "powershell", tmpFile.Name())
 	} else {
-		cmd = exec.Command("bash", tmpFile.Name())
+		cmd = exec.CommandContext(ctx, "bash", tmpFile.Name())
 	}
+	killGroup(cmd)
+	cmd.WaitDelay = waitDelay
+	cmd.Dir = dir
 
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	setup(cmd)
 
 	if err := cmd.Run(); err != nil {
 		return err
@@ -83,6 +100,15 @@ func OnceScript(s string) error {
 	return nil
 }
 
+// Quote returns s quoted as a single argument for the shell running the scripts,
+// in single quotes for bash and in double quotes on Windows.
+func Quote(s string) string {
+	if aos.IsWindows {
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 // LookPath searches for an executable file in the system's PATH and returns its full path.
 func LookPath(file string) (string, error) {
 	log.Debugf("looking path %s", file)
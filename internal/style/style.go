@@ -12,4 +12,6 @@ var (
 		Foreground(lipgloss.AdaptiveColor{Light: "#FF4672", Dark: "#ED567A"}).
 		Background(lipgloss.AdaptiveColor{Light: "#DDDADA", Dark: "#242424"}).
 		Render
+	Added   = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#1A7F37", Dark: "#3FB950"}).Render
+	Removed = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#CF222E", Dark: "#F85149"}).Render
 )
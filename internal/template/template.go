@@ -5,16 +5,17 @@ package template
 
 import (
 	"aliax/internal/aos"
-	"html/template"
 	"io"
 	"os"
+	"text/template"
 
 	"github.com/caarlos0/log"
 )
 
 // Execute processes a template string `s` with the provided `data` and writes the result to the `w` writer.
 // It uses the `aliaxFuncs` function map to add custom functions to the template.
-func Execute(w io.Writer, s string, data map[string]string) error {
+// The output isn't escaped, templates render shell commands rather than HTML.
+func Execute(w io.Writer, s string, data any) error {
 	log.Debugf("executing template")
 	tmpl, err := template.New("").Funcs(aliaxFuncs).Parse(s)
 	if err != nil {
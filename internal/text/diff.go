@@ -0,0 +1,120 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package text
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffLine is a line of a diff. Op is ' ' for an unchanged line,
+// '-' for a removed line and '+' for an added one.
+type diffLine struct {
+	Op   byte
+	Text string
+}
+
+func (l diffLine) String() string {
+	return string(l.Op) + l.Text
+}
+
+// Diff compares a and b line by line and returns the hunks of changed lines,
+// each one surrounded by up to context unchanged lines and introduced by
+// a "@@ -start,count +start,count @@" header like in unified diffs.
+func Diff(a, b string, context int) []string {
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	hunks := []string{}
+	for i := 0; i < len(lines); {
+		if lines[i].Op == ' ' {
+			i++
+			continue
+		}
+		start := max(i-context, 0)
+		end := i
+		// extend the hunk while the next change is close enough
+		for j := i; j < len(lines); j++ {
+			if lines[j].Op != ' ' {
+				end = j
+			} else if j-end > 2*context {
+				break
+			}
+		}
+		end = min(end+context+1, len(lines))
+
+		oldStart, newStart := 1, 1
+		for _, l := range lines[:start] {
+			if l.Op != '+' {
+				oldStart++
+			}
+			if l.Op != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, l := range lines[start:end] {
+			if l.Op != '+' {
+				oldCount++
+			}
+			if l.Op != '-' {
+				newCount++
+			}
+		}
+		hunks = append(hunks, fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldStart, oldCount, newStart, newCount))
+		for _, l := range lines[start:end] {
+			hunks = append(hunks, l.String())
+		}
+		i = end
+	}
+	return hunks
+}
+
+func splitLines(s string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes the shortest edit script between a and b
+// from their longest common subsequence.
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := []diffLine{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{'+', b[j]})
+	}
+	return lines
+}
@@ -0,0 +1,22 @@
+// Copyright 2025 The Aliax Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package token
+
+type Token int
+
+const (
+	AND Token = iota // and
+	OR               // or
+
+	EQ // =
+	GT // -gt
+
+	STRING
+	NUMBER
+	BOOL
+)
+
+func (t Token) String() string {
+	return []string{"and", "or", "=", "-gt"}[t]
+}